@@ -0,0 +1,55 @@
+package crown
+
+// WithAutoAdvance creates the clock with auto-advance enabled, see
+// SetAutoAdvance.
+func WithAutoAdvance() Option {
+	return func(c *Clock) {
+		c.auto = make(chan struct{})
+	}
+}
+
+// SetAutoAdvance turns auto-advance on or off. When it is on, the clock moves
+// to its next deadline by itself, like AdvanceToNext, as soon as the number of
+// waiters is stable after yielding to the other goroutines: the code under
+// test then runs its timeouts, retries and sleeps without any call to
+// Forward, and as fast as possible.
+//
+// The clock cannot tell a goroutine blocked on it from one busy with other
+// work, so auto-advance suits straightforward code, whose goroutines only
+// compute briefly between waits. A running ticker keeps the clock moving
+// until it is stopped. Closing the clock turns auto-advance off.
+func (c *Clock) SetAutoAdvance(on bool) {
+	c.autoMu.Lock()
+	defer c.autoMu.Unlock()
+	switch {
+	case on && c.auto == nil && !c.isClosed():
+		c.auto = make(chan struct{})
+		go c.autoAdvance(c.auto)
+	case !on && c.auto != nil:
+		close(c.auto)
+		c.auto = nil
+	}
+}
+
+// autoAdvance runs the goroutine moving the clock, until stop is closed.
+func (c *Clock) autoAdvance(stop chan struct{}) {
+	for {
+		changed := c.waitersChanged()
+		c.settle()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if c.Waiters() > 0 {
+			if _, ok := c.AdvanceToNext(); ok {
+				continue
+			}
+		}
+		select {
+		case <-changed:
+		case <-stop:
+			return
+		}
+	}
+}
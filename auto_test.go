@@ -0,0 +1,60 @@
+package crown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAutoAdvance(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-06T09:00:00Z")
+	clock := NewClock(refT, WithAutoAdvance())
+	defer clock.Close()
+
+	// A timeout fires without any call to Forward.
+	ctx, cancel := clock.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.Errorf("Should be %v, got %v instead", context.DeadlineExceeded, ctx.Err())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timeout did not fire")
+	}
+
+	// Chained sleeps run one after the other.
+	start := clock.Now()
+	for i := 0; i < 10; i++ {
+		clock.Sleep(time.Minute)
+	}
+	if got := clock.Now().Sub(start); got != 10*time.Minute {
+		t.Errorf("Should move by 10 minutes, moved by %s", got)
+	}
+
+	clock.SetAutoAdvance(false)
+	timer := clock.NewTimer(time.Second)
+	select {
+	case <-timer.C:
+		t.Errorf("Timer fired with auto-advance off")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.SetAutoAdvance(true)
+	select {
+	case <-timer.C:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Timer did not fire once auto-advance is on again")
+	}
+}
+
+func TestAutoAdvanceClose(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-06T09:00:00Z")
+	clock := NewClock(refT)
+	clock.SetAutoAdvance(true)
+	clock.Close()
+	clock.SetAutoAdvance(true)
+	if clock.auto != nil {
+		t.Errorf("Auto-advance should stay off once the clock is closed")
+	}
+}
@@ -0,0 +1,90 @@
+package crown
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// BenchmarkForward measures an advance releasing a single timer, while many
+// others are pending.
+func BenchmarkForward(b *testing.B) {
+	for _, pending := range []int{100, 10000, 100000} {
+		b.Run(fmt.Sprintf("pending=%d", pending), func(b *testing.B) {
+			clock := NewClock(time.Time{})
+			defer clock.Close()
+			for i := 0; i < pending; i++ {
+				clock.AfterFunc(time.Hour, func() {})
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				clock.schedule(&sleepHandler{deadline: clock.Now().Add(time.Nanosecond), fire: func(error) {}})
+				clock.Forward(time.Nanosecond)
+			}
+		})
+	}
+}
+
+// BenchmarkTimers measures the creation and stop of timers, while many others
+// are pending.
+// BenchmarkNow measures concurrent reads of the clock while it moves.
+func BenchmarkNow(b *testing.B) {
+	clock := NewClock(time.Time{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				clock.Forward(time.Nanosecond)
+			}
+		}
+	}()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			clock.Now()
+		}
+	})
+}
+
+func BenchmarkTimers(b *testing.B) {
+	clock := NewClock(time.Time{})
+	defer clock.Close()
+	for i := 0; i < 10000; i++ {
+		clock.AfterFunc(time.Hour, func() {})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clock.AfterFunc(time.Minute, func() {}).Stop()
+	}
+}
+
+// BenchmarkQueue measures the registration of coarse-grained timeouts, and
+// their release by advances of a second, per timeout.
+func BenchmarkQueue(b *testing.B) {
+	for _, queue := range []struct {
+		name string
+		opts []Option
+	}{
+		{"heap", nil},
+		{"wheel", []Option{WithTimerWheel(time.Millisecond)}},
+	} {
+		b.Run(queue.name, func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			clock := NewClock(time.Time{}, queue.opts...)
+			fire := func(error) {}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d := time.Duration(rng.Intn(3600)) * time.Second
+				clock.schedule(&sleepHandler{deadline: clock.Now().Add(d + time.Second), fire: fire})
+			}
+			for clock.Waiters() > 0 {
+				clock.Forward(time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package certs verifies x509 certificates as of the time of a crown clock,
+// and moves the clock across their validity boundaries, for testing
+// certificate rotation and expiry alerting.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// VerifyOptions returns a copy of opts verifying certificates as of the
+// current time of the clock c.
+func VerifyOptions(c *crown.Clock, opts x509.VerifyOptions) x509.VerifyOptions {
+	opts.CurrentTime = c.Now()
+	return opts
+}
+
+// Verify verifies cert with opts as of the current time of the clock c.
+func Verify(c *crown.Clock, cert *x509.Certificate, opts x509.VerifyOptions) ([][]*x509.Certificate, error) {
+	return cert.Verify(VerifyOptions(c, opts))
+}
+
+// Valid reports whether the current time of the clock c is within the
+// validity period of cert, bounds included.
+func Valid(c *crown.Clock, cert *x509.Certificate) bool {
+	now := c.Now()
+	return !now.Before(cert.NotBefore) && !now.After(cert.NotAfter)
+}
+
+// Remaining returns how long cert remains valid at the current time of the
+// clock c. It is negative once cert has expired.
+func Remaining(c *crown.Clock, cert *x509.Certificate) time.Duration {
+	return cert.NotAfter.Sub(c.Now())
+}
+
+// ForwardToNotBefore moves the clock c to offset after the start of the
+// validity of cert; a negative offset moves it right before. It fails if that
+// time is already past.
+func ForwardToNotBefore(c *crown.Clock, cert *x509.Certificate, offset time.Duration) error {
+	return forwardTo(c, cert.NotBefore.Add(offset))
+}
+
+// ForwardToNotAfter moves the clock c to offset after the end of the validity
+// of cert; a negative offset moves it right before, for instance to trigger
+// expiry alerts. It fails if that time is already past.
+func ForwardToNotAfter(c *crown.Clock, cert *x509.Certificate, offset time.Duration) error {
+	return forwardTo(c, cert.NotAfter.Add(offset))
+}
+
+func forwardTo(c *crown.Clock, t time.Time) error {
+	d := t.Sub(c.Now())
+	if d < 0 {
+		return fmt.Errorf("certs: %s is %s in the past of the clock", t.Format(time.RFC3339), -d)
+	}
+	c.Forward(d)
+	return nil
+}
+
+// NewSelfSigned returns a self-signed CA certificate for commonName, valid
+// for the given duration from the current time of the clock c, along with its
+// private key.
+func NewSelfSigned(c *crown.Clock, commonName string, validity time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := c.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             now,
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
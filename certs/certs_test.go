@@ -0,0 +1,49 @@
+package certs
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestExpiry(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-27T09:00:00Z")
+	clock := crown.NewClock(refT)
+	cert, _, err := NewSelfSigned(clock, "example.test", 90*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	opts := x509.VerifyOptions{Roots: roots, DNSName: "example.test"}
+
+	if _, err := Verify(clock, cert, opts); err != nil {
+		t.Fatalf("Certificate should be valid when issued, got %v", err)
+	}
+	if err := ForwardToNotAfter(clock, cert, -7*24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if got := Remaining(clock, cert); got != 7*24*time.Hour {
+		t.Errorf("Should remain valid for a week, got %v", got)
+	}
+	if !Valid(clock, cert) {
+		t.Errorf("Certificate should still be valid")
+	}
+
+	if err := ForwardToNotAfter(clock, cert, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var invalid x509.CertificateInvalidError
+	if _, err := Verify(clock, cert, opts); !errors.As(err, &invalid) || invalid.Reason != x509.Expired {
+		t.Errorf("Certificate should have expired, got %v", err)
+	}
+	if Valid(clock, cert) {
+		t.Errorf("Certificate should not be valid anymore")
+	}
+	if err := ForwardToNotBefore(clock, cert, 0); err == nil {
+		t.Errorf("Should not move the clock backward")
+	}
+}
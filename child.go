@@ -0,0 +1,21 @@
+package crown
+
+import "time"
+
+// Child returns a new clock, created with opts, which reads the time of c
+// shifted by offset, and moves whenever c moves, releasing its own waits like
+// Set. Tests of multi-tenant or multi-region systems can then give each
+// component a clock of its own, offset from the others, and move them all
+// with a single Forward on c. A child can have children of its own.
+//
+// The child follows the moves of c, through OnAdvance, and not the flow of
+// time of a clock created with WithTimeScale between two moves. It is moved by
+// the goroutine moving c, before the waits of c are released. The child is
+// expected to be moved through c only, and stops following c once closed.
+func (c *Clock) Child(offset time.Duration, opts ...Option) *Clock {
+	child := NewClock(c.Now().Add(offset), opts...)
+	child.detach = c.OnAdvance(func(_, now time.Time) {
+		child.Set(now.Add(offset))
+	})
+	return child
+}
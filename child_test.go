@@ -0,0 +1,37 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChild(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-16T09:00:00Z")
+	parent := NewClock(refT)
+	child := parent.Child(-time.Hour, WithWakeAck())
+	grandchild := child.Child(30 * time.Minute)
+	if got := child.Now(); !got.Equal(refT.Add(-time.Hour)) {
+		t.Errorf("Child should start an hour behind, got %s", got)
+	}
+
+	timer := child.NewTimer(time.Minute)
+	parent.Forward(time.Minute)
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer of the child should fire with the parent")
+	}
+	if got := grandchild.Since(refT); got != -29*time.Minute {
+		t.Errorf("Grandchild should be 30 minutes ahead of the child, got %s", got)
+	}
+	parent.Set(refT)
+	if got := child.Since(refT); got != -time.Hour {
+		t.Errorf("Child should follow the parent back, got %s", got)
+	}
+
+	child.Close()
+	parent.Forward(time.Hour)
+	if got := child.Since(refT); got != -time.Hour {
+		t.Errorf("Closed child should stop following the parent, got %s", got)
+	}
+}
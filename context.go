@@ -0,0 +1,148 @@
+package crown
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Context is a context.Context whose deadline is measured on a Clock: it is
+// done once the clock reaches its deadline, and its Deadline method reports a
+// time of the clock. It is returned by Clock.WithDeadline and
+// Clock.WithTimeout, and its clock can be retrieved from contexts derived from
+// it with FromContext.
+type Context struct {
+	parent     context.Context
+	clock      *Clock
+	deadline   time.Time
+	key        uint64
+	registered bool
+
+	mu   sync.Mutex
+	done chan struct{}
+	err  error
+}
+
+// WithDeadline is the equivalent of context.WithDeadline: it returns a copy of
+// parent which is done once the clock reaches d, when the returned cancel
+// function is called, or when parent is done, whichever happens first. Its
+// Deadline method reports d, and its Err method returns
+// context.DeadlineExceeded once d is reached. If the clock is closed or its
+// waits are canceled, the context is canceled.
+func (c *Clock) WithDeadline(parent context.Context, d time.Time) (*Context, context.CancelFunc) {
+	ctx := &Context{parent: parent, clock: c, deadline: d, done: make(chan struct{})}
+	if err := parent.Err(); err != nil {
+		ctx.finish(err)
+		return ctx, func() {}
+	}
+	ctx.key, ctx.registered = c.schedule(&sleepHandler{
+		deadline: d,
+		origin:   c.origin(parent, "context"),
+		fire: func(err error) {
+			if err == nil {
+				ctx.finish(context.DeadlineExceeded)
+			} else {
+				ctx.finish(context.Canceled)
+			}
+		},
+	})
+	if parent.Done() != nil {
+		go ctx.watchParent()
+	}
+	return ctx, func() { ctx.cancel(context.Canceled) }
+}
+
+// WithTimeout returns WithDeadline(parent, c.Now().Add(timeout)).
+func (c *Clock) WithTimeout(parent context.Context, timeout time.Duration) (*Context, context.CancelFunc) {
+	return c.WithDeadline(parent, c.Now().Add(timeout))
+}
+
+// clockKey is the key of the clock of a Context among the values of contexts.
+type clockKey struct{}
+
+// FromContext returns the clock measuring the deadline of ctx, if ctx is or
+// derives from a Context.
+func FromContext(ctx context.Context) (*Clock, bool) {
+	c, ok := ctx.Value(clockKey{}).(*Clock)
+	return c, ok
+}
+
+// Clock returns the clock measuring the deadline of the context.
+func (ctx *Context) Clock() *Clock {
+	return ctx.clock
+}
+
+// Remaining returns the duration until the deadline of the context, according
+// to its clock. It is the equivalent of time.Until(deadline) for contexts of
+// the context package.
+func (ctx *Context) Remaining() time.Duration {
+	d, _ := ctx.Deadline()
+	return ctx.clock.Until(d)
+}
+
+// Deadline returns the deadline of the context, as a time of its clock. It is
+// the deadline of the parent context instead if the parent is measured on the
+// same clock and its deadline is earlier.
+func (ctx *Context) Deadline() (time.Time, bool) {
+	if c, ok := FromContext(ctx.parent); ok && c == ctx.clock {
+		if d, ok := ctx.parent.Deadline(); ok && d.Before(ctx.deadline) {
+			return d, true
+		}
+	}
+	return ctx.deadline, true
+}
+
+// Done returns a channel closed once the context is done.
+func (ctx *Context) Done() <-chan struct{} {
+	return ctx.done
+}
+
+// Err returns nil while the context is not done, and then
+// context.DeadlineExceeded if its deadline was reached, or the reason it was
+// canceled.
+func (ctx *Context) Err() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.err
+}
+
+// Value returns the value of the parent context for key.
+func (ctx *Context) Value(key any) any {
+	if key == (clockKey{}) {
+		return ctx.clock
+	}
+	return ctx.parent.Value(key)
+}
+
+func (ctx *Context) String() string {
+	return "crown.WithDeadline(" + ctx.deadline.String() + ")"
+}
+
+// watchParent runs the goroutine canceling the context when its parent is
+// done, until either is.
+func (ctx *Context) watchParent() {
+	select {
+	case <-ctx.parent.Done():
+		ctx.cancel(ctx.parent.Err())
+	case <-ctx.done:
+	}
+}
+
+// cancel deregisters the context from its clock and finishes it with err.
+func (ctx *Context) cancel(err error) {
+	if ctx.registered {
+		ctx.clock.cancel(ctx.key, err)
+	}
+	ctx.finish(err)
+}
+
+// finish makes the context done with err, unless it is already.
+func (ctx *Context) finish(err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.err != nil {
+		return
+	}
+	ctx.err = err
+	close(ctx.done)
+}
@@ -0,0 +1,103 @@
+package crown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-02T09:00:00Z")
+	clock := NewClock(refT)
+	ctx, cancel := clock.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(refT.Add(time.Minute)) {
+		t.Errorf("Should have deadline %q, got %q", refT.Add(time.Minute), d)
+	}
+	clock.Forward(59 * time.Second)
+	select {
+	case <-ctx.Done():
+		t.Fatalf("Done before the deadline: %v", ctx.Err())
+	default:
+	}
+	clock.Forward(time.Second)
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatalf("Not done at the deadline")
+	}
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Errorf("Should fail with DeadlineExceeded, got %v", err)
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Should have no waiter left, got %d", clock.Waiters())
+	}
+}
+
+func TestWithDeadlineCancel(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-02T09:00:00Z")
+	clock := NewClock(refT)
+
+	ctx, cancel := clock.WithDeadline(context.Background(), refT.Add(time.Minute))
+	cancel()
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.Canceled {
+		t.Errorf("Should fail with Canceled, got %v", err)
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Cancel should deregister the context, got %d waiters", clock.Waiters())
+	}
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel = clock.WithDeadline(parent, refT.Add(time.Minute))
+	defer cancel()
+	cancelParent()
+	<-ctx.Done()
+	if err := ctx.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Should fail with the error of the parent, got %v", err)
+	}
+
+	ctx, cancel = clock.WithDeadline(context.Background(), refT)
+	defer cancel()
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Errorf("A past deadline should be exceeded right away, got %v", err)
+	}
+}
+
+func TestContextDerived(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-02T09:00:00Z")
+	clock := NewClock(refT)
+	type key struct{}
+	outer, cancel := clock.WithTimeout(context.WithValue(context.Background(), key{}, "v"), time.Minute)
+	defer cancel()
+	inner, cancelInner := clock.WithTimeout(outer, time.Hour)
+	defer cancelInner()
+
+	// Derived contexts keep the deadline of the clock, and the clock itself.
+	derived, cancelDerived := context.WithCancel(inner)
+	defer cancelDerived()
+	if d, _ := derived.Deadline(); !d.Equal(refT.Add(time.Minute)) {
+		t.Errorf("Should inherit the earlier deadline %q, got %q", refT.Add(time.Minute), d)
+	}
+	if c, ok := FromContext(derived); !ok || c != clock {
+		t.Errorf("Should retrieve the clock from a derived context")
+	}
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("Should not retrieve a clock from a context not bound to one")
+	}
+	if got := derived.Value(key{}); got != "v" {
+		t.Errorf("Should keep the values of the parent, got %v", got)
+	}
+
+	clock.Forward(20 * time.Second)
+	if got := inner.Remaining(); got != 40*time.Second {
+		t.Errorf("Should remain 40s, got %v", got)
+	}
+	clock.Forward(40 * time.Second)
+	<-derived.Done()
+	if err := inner.Err(); err != context.DeadlineExceeded {
+		t.Errorf("Should fail with the error of the parent, got %v", err)
+	}
+}
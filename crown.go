@@ -2,114 +2,678 @@ package crown
 
 import (
 	"context"
+	"math/rand"
+	"runtime"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
 // Clock represents a controllable clock. The function NewClock returns a new
-// one, so there is no need to initialize Clock directly. A Clock object must
-// not be copied.
+// one starting at a given time. The zero value for a Clock is ready to use and
+// starts at the zero time, so a Clock can be embedded in other structs without
+// any initialization. On 32-bit platforms, a Clock embedded by value must be
+// the first field of its struct, as it holds 64-bit values updated
+// atomically: other fields should hold it by pointer. A Clock object must not
+// be copied after first use.
 type Clock struct {
-	mu         sync.RWMutex
-	current    time.Time
-	handlers   sync.Map
-	sleepCount int32
-}
+	noCopy noCopy
+
+	// The 64-bit values updated atomically come first to be 64-bit aligned
+	// on 32-bit platforms, as long as the Clock itself is, see above.
+	lastID   uint64 // last key given to a handler
+	expected int64  // number of waits expected in strict mode
+
+	mu         sync.Mutex   // serializes the moves of the clock
+	current    atomic.Value // *instant, loaded without locking by Now
+	sleepCount int32        // number of waits started, for GetSleepCount
+	waiters    int32
+	closed     int32
+
+	waitersMu sync.Mutex    // guards queue, and serializes the changes of waiters
+	queue     waitQueue     // registered handlers, a heapQueue unless set
+	notify    chan struct{} // closed at the next change of waiters, if not nil
+
+	statsMu sync.Mutex
+	stats   Stats
+	funcs   funcGroup
 
-type Timer struct {
-	C      <-chan time.Time
-	cancel func()
+	autoMu sync.Mutex
+	auto   chan struct{} // closed to stop auto-advance, nil if off
+
+	factor    float64       // time scale, see WithTimeScale, or 0
+	watchdog  *watchdog     // see WithWatchdog, or nil
+	strict    func(error)   // see WithStrict, or nil
+	scaleStop chan struct{} // closed to stop following the wall time, guarded by mu
+
+	randOnce sync.Once
+	rand     *rand.Rand // see Rand, set once by initRand
+	seed     int64
+	jitter   time.Duration  // see WithTimerJitter
+	detach   func()         // stops following the parent, see Child, or nil
+	loc      *time.Location // see WithLocation, or nil
+
+	ticksMu sync.Mutex
+	ticks   map[<-chan time.Time]*Ticker // tickers of Tick, by channel
+
+	wakeAck     bool
+	monotonic   bool
+	policy      DeadlinePolicy
+	stepwise    bool
+	stdChannels bool
+	stacks      bool
+	tracer      func(context.Context) string
+	name        string
+	labels      bool
+	observersMu sync.Mutex   // serializes the changes of observers
+	observers   atomic.Value // []*observer, replaced on change
 }
 
 type sleepHandler struct {
+	origin
+	id       uint64    // key of the handler, set once registered
+	index    int       // position of the handler in its heap or wheel slot
+	slot     int       // wheel slot of the handler, or -1 if in a heap
+	start    time.Time // time of the clock when the wait was registered
 	deadline time.Time
-	c        chan struct{}
+	fire     func(err error) // wakes the waiter up, interrupted unless err is nil
+	ack      chan struct{}   // nil unless the waiter acknowledges its resumption
 }
 
+// before reports whether h fires before other: by deadline, then in
+// registration order.
+func (h *sleepHandler) before(other *sleepHandler) bool {
+	if !h.deadline.Equal(other.deadline) {
+		return h.deadline.Before(other.deadline)
+	}
+	return h.id < other.id
+}
+
+// resume acknowledges that the sleeper has resumed. It must be called exactly
+// once for each registered handler, whatever the reason it returned.
+func (h *sleepHandler) resume() {
+	if h != nil && h.ack != nil {
+		close(h.ack)
+	}
+}
+
+// noCopy may be embedded into structs which must not be copied after the
+// first use, so that `go vet` reports accidental copies.
+// See https://golang.org/issues/8005#issuecomment-190753527.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
 // NewClock initializes and returns a new Clock object which starts at time t.
-func NewClock(t time.Time) *Clock {
+func NewClock(t time.Time, opts ...Option) *Clock {
 	clock := new(Clock)
-	clock.current = t
+	clock.current.Store(&instant{t: t})
+	for _, opt := range opts {
+		opt(clock)
+	}
+	if clock.loc != nil {
+		clock.current.Store(&instant{t: t.In(clock.loc)})
+	}
+	if clock.auto != nil {
+		go clock.autoAdvance(clock.auto)
+	}
+	if clock.factor != 0 {
+		clock.Resume()
+	}
+	if clock.watchdog != nil {
+		go clock.watchdog.run(clock)
+	}
 	return clock
 }
 
+// GetSleepCount returns the number of waits started on the clock so far.
+//
+// Deprecated: the counter never decreases, which makes it unreliable to
+// synchronize with sleepers. Use Stats or Waiters instead.
 func (c *Clock) GetSleepCount() int32 {
 	return atomic.LoadInt32(&c.sleepCount)
 }
 
-// Now returns the current clock time.
+// Waiters returns the number of sleepers, timers and tickers currently blocked
+// on the clock. Unlike GetSleepCount, it decreases when a wait is released or
+// canceled.
+func (c *Clock) Waiters() int {
+	return int(atomic.LoadInt32(&c.waiters))
+}
+
+// PendingTimers returns the number of timers and tickers currently registered
+// on the clock, that is neither fired nor stopped. Along with Waiters, it lets
+// tests assert that the code under test does not leak timers.
+func (c *Clock) PendingTimers() int {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+	n := 0
+	for _, handler := range c.waits().handlers() {
+		if handler.kind == "timer" || handler.kind == "ticker" {
+			n++
+		}
+	}
+	return n
+}
+
+// BlockUntil blocks until exactly n sleepers, timers and tickers are waiting
+// on the clock, see Waiters. It lets tests synchronize with the goroutines
+// under test before moving the clock.
+func (c *Clock) BlockUntil(n int) {
+	c.BlockUntilContext(context.Background(), n)
+}
+
+// BlockUntilContext is like BlockUntil, but returns ctx's error if ctx is done
+// first.
+func (c *Clock) BlockUntilContext(ctx context.Context, n int) error {
+	for {
+		changed := c.waitersChanged()
+		if c.Waiters() == n {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitersChanged returns a channel closed at the next change of the number
+// of waiters.
+func (c *Clock) waitersChanged() <-chan struct{} {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+	if c.notify == nil {
+		c.notify = make(chan struct{})
+	}
+	return c.notify
+}
+
+// addWaiters adds delta to the number of waiters, and returns it. It must be
+// called with waitersMu held, once handlers reflects the change, so that the
+// number of waiters never counts a handler not stored yet.
+func (c *Clock) addWaiters(delta int32) int32 {
+	n := atomic.AddInt32(&c.waiters, delta)
+	if c.notify != nil {
+		close(c.notify)
+		c.notify = nil
+	}
+	return n
+}
+
+// Now returns the current clock time. It does not lock, so that it can be
+// called in hot loops, concurrently with the moves of the clock.
 func (c *Clock) Now() time.Time {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.current
+	if i, ok := c.current.Load().(*instant); ok && i.factor == 0 {
+		return i.t
+	}
+	return c.flowingNow()
+}
+
+// NowIn returns the current time of the clock in loc. It is shorthand for
+// c.Now().In(loc).
+func (c *Clock) NowIn(loc *time.Location) time.Time {
+	return c.Now().In(loc)
+}
+
+// Since returns the time elapsed on the clock since t, like time.Since. It is
+// shorthand for c.Now().Sub(t).
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Until returns the duration until t according to the clock, like time.Until.
+// It is shorthand for t.Sub(c.Now()).
+func (c *Clock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
 }
 
 // Forward makes a forward time travel according to the specified duration d.
+// The waits it releases fire in the order of their deadlines, and those with
+// the same deadline in the order they were registered. If the clock was
+// created with WithWakeAck, each released sleeper resumes before the next one
+// is released, and Forward returns only once all of them have. If the clock
+// was created with WithStrictMonotonic, Forward panics with ErrNonMonotonic
+// when d is negative.
 func (c *Clock) Forward(d time.Duration) {
+	c.ForwardN(d)
+}
+
+// ForwardAndWait is like Forward, but always returns only once every released
+// sleeper has resumed, as if the clock was created with WithWakeAck: released
+// Sleep calls are returning, and fired Timers have sent the time on their
+// channel. Functions of AfterFunc timers are only started, see WaitFuncs.
+func (c *Clock) ForwardAndWait(d time.Duration) {
+	c.advance(true, func(now time.Time) time.Time {
+		return now.Add(d)
+	})
+}
+
+// ForwardN is like Forward, but returns the number of sleepers, timers and
+// tickers released by the advance.
+func (c *Clock) ForwardN(d time.Duration) int {
+	return c.advance(c.wakeAck, func(now time.Time) time.Time {
+		return now.Add(d)
+	})
+}
+
+// Set moves the clock to the absolute time t, releasing the sleepers, timers
+// and tickers whose deadline is reached, like Forward. If t is before the
+// current time, the clock moves backward and releases nothing, unless the
+// clock was created with WithStrictMonotonic, in which case Set panics with
+// ErrNonMonotonic.
+func (c *Clock) Set(t time.Time) {
+	c.advance(c.wakeAck, func(time.Time) time.Time {
+		return t
+	})
+}
+
+// ForwardTo is an alias for Set.
+func (c *Clock) ForwardTo(t time.Time) {
+	c.Set(t)
+}
+
+// NextDeadline returns the earliest deadline among the sleepers, timers and
+// tickers waiting on the clock. It reports false if there is none.
+func (c *Clock) NextDeadline() (time.Time, bool) {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+	return c.waits().next()
+}
+
+// AdvanceToNext moves the clock exactly to its next deadline, see
+// NextDeadline, releasing the waits due at that time like Forward. It returns
+// the duration the clock moved by, or reports false, without moving the clock,
+// if no wait is pending.
+func (c *Clock) AdvanceToNext() (time.Duration, bool) {
+	next, ok := c.NextDeadline()
+	if !ok {
+		return 0, false
+	}
+	var d time.Duration
+	c.advance(c.wakeAck, func(now time.Time) time.Time {
+		if next.Before(now) {
+			// Another advance went past the deadline meanwhile.
+			return now
+		}
+		d = next.Sub(now)
+		return next
+	})
+	return d, true
+}
+
+// RunUntilIdle repeatedly moves the clock to its next deadline, see
+// AdvanceToNext, until no wait is pending anymore, and returns the total
+// duration the clock moved by. Between advances, it yields to the released
+// goroutines until the number of waiters is stable, so that chained waits,
+// such as retries with backoff, are registered in time. A running ticker keeps
+// the clock busy forever: it must be stopped for RunUntilIdle to return.
+func (c *Clock) RunUntilIdle() time.Duration {
+	var total time.Duration
+	for {
+		c.settle()
+		d, ok := c.AdvanceToNext()
+		if !ok {
+			return total
+		}
+		total += d
+	}
+}
+
+// settle yields to the other goroutines until the number of waiters is
+// stable.
+func (c *Clock) settle() {
+	for stable, last := 0, -1; stable < 3; {
+		runtime.Gosched()
+		if n := c.Waiters(); n == last {
+			stable++
+		} else {
+			stable, last = 0, n
+		}
+	}
+}
+
+// advance moves the clock to the time returned by target for its current
+// time, and returns the number of released waits, waiting for them to resume
+// if wait is true. If the clock steps through deadlines, it first stops at
+// each deadline before that time.
+func (c *Clock) advance(wait bool, target func(now time.Time) time.Time) int {
 	c.mu.Lock()
-	c.current = c.current.Add(d)
+	prev, wall := c.load()
+	end := target(prev)
+	if end.Before(prev) && c.monotonic {
+		c.mu.Unlock()
+		panic(ErrNonMonotonic)
+	}
+	if !c.stepwise {
+		c.store(end, wall)
+		c.mu.Unlock()
+		return c.releaseDue(prev, end, wait)
+	}
 	c.mu.Unlock()
+	n := 0
+	for {
+		c.mu.Lock()
+		prev, wall = c.load()
+		next, ok := c.deadlineAfter(prev)
+		if !ok || !next.Before(end) {
+			c.store(end, wall)
+			c.mu.Unlock()
+			return n + c.releaseDue(prev, end, wait)
+		}
+		c.store(next, wall)
+		c.mu.Unlock()
+		n += c.releaseDue(prev, next, wait)
+	}
+}
+
+// load returns the current time of the clock, and the wall time it matches if
+// the clock follows the wall time.
+func (c *Clock) load() (now, wall time.Time) {
+	i, _ := c.current.Load().(*instant)
+	switch {
+	case i == nil:
+		return now, wall
+	case i.factor != 0:
+		wall = time.Now()
+		return i.at(wall), wall
+	}
+	return i.t, wall
+}
+
+// store sets the current time of the clock to t, which matches the wall time
+// wall if the clock follows the wall time. It must be called with mu held.
+func (c *Clock) store(t, wall time.Time) {
+	if c.loc != nil {
+		t = t.In(c.loc)
+	}
+	i, _ := c.current.Load().(*instant)
+	if i != nil && i.factor != 0 {
+		c.current.Store(&instant{t: t, wall: wall, factor: i.factor})
+		return
+	}
+	c.current.Store(&instant{t: t})
+}
+
+// deadlineAfter returns the earliest deadline of the waits after t, and
+// reports false if there is none.
+func (c *Clock) deadlineAfter(t time.Time) (time.Time, bool) {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+	return c.waits().deadlineAfter(t)
+}
+
+// releaseDue accounts for the move of the clock from prev to now, and releases
+// the waits due at now, waiting for each to resume if wait is true. It returns
+// their number.
+func (c *Clock) releaseDue(prev, now time.Time, wait bool) int {
+	d := now.Sub(prev)
+	c.statAdvance(d, now)
+	if c.observed() {
+		c.emit(Event{Kind: EventAdvance, Time: now, Advance: d})
+	}
 
-	// Broadcast
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	c.handlers.Range(func(key, val any) bool {
-		handler := val.(*sleepHandler)
-		if c.current.Before(handler.deadline) {
-			return true
+	// Broadcast, in the order of deadlines, then of registration. The waits
+	// registered meanwhile are left for the next advance.
+	last := atomic.LoadUint64(&c.lastID)
+	released := 0
+	for {
+		handler := c.popDue(now, last)
+		if handler == nil {
+			break
 		}
-		close(handler.c)
-		c.handlers.Delete(key)
-		return true
+		c.fire(handler, nil)
+		released++
+		// Waiting for each sleeper in turn makes them resume in order.
+		if wait && handler.ack != nil {
+			<-handler.ack
+		}
+	}
+	return released
+}
+
+// sortHandlers sorts handlers by deadline, then by registration order.
+func sortHandlers(handlers []*sleepHandler) {
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].before(handlers[j])
 	})
 }
 
+// popDue deregisters and returns the first handler due at now, among those
+// registered up to key last. It returns nil if there is none.
+func (c *Clock) popDue(now time.Time, last uint64) *sleepHandler {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+	handler := c.waits().popDue(now, last)
+	if handler != nil {
+		c.addWaiters(-1)
+	}
+	return handler
+}
+
+// waits returns the queue of the clock. It must be called with waitersMu
+// held.
+func (c *Clock) waits() waitQueue {
+	if c.queue == nil {
+		c.queue = new(heapQueue)
+	}
+	return c.queue
+}
+
+// Close closes the clock: every sleeper still blocked on it is released,
+// SleepWithContext calls failing with ErrClockClosed, and the pending timers
+// and tickers are stopped. Once closed, the clock does not block anymore: Sleep
+// returns immediately and new timers and tickers are created stopped. Close
+// can be called several times and always returns nil.
+func (c *Clock) Close() error {
+	atomic.StoreInt32(&c.closed, 1)
+	c.SetAutoAdvance(false)
+	c.Pause()
+	if c.watchdog != nil {
+		c.watchdog.close()
+	}
+	if c.detach != nil {
+		c.detach()
+	}
+	c.releaseAll(ErrClockClosed)
+	return nil
+}
+
+// CancelAll interrupts every wait currently blocked on the clock, without
+// closing it: SleepWithContext calls fail with ErrCanceled, and the pending
+// timers and tickers are stopped. It returns the number of interrupted waits.
+func (c *Clock) CancelAll() int {
+	return c.releaseAll(ErrCanceled)
+}
+
+// releaseAll releases every registered sleeper with err, and returns their
+// number. The tickers of Tick, which are stopped, are forgotten.
+func (c *Clock) releaseAll(err error) int {
+	c.ticksMu.Lock()
+	c.ticks = nil
+	c.ticksMu.Unlock()
+	n := 0
+	for _, handler := range c.pendingHandlers() {
+		if c.release(handler.id, handler, err) {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *Clock) isClosed() bool {
+	return atomic.LoadInt32(&c.closed) != 0
+}
+
 // Sleep returns when the clock has reached its curent time + the specified
 // duration d.
 func (c *Clock) Sleep(d time.Duration) {
 	c.SleepWithContext(context.Background(), d)
 }
 
+// SleepWithContext is like Sleep, but returns early when ctx is done, the clock
+// is closed or its waits are canceled. The returned error is then a
+// *WaitError wrapping the cause: ctx's error, ErrClockClosed or ErrCanceled.
 func (c *Clock) SleepWithContext(ctx context.Context, d time.Duration) error {
-	handlerID := atomic.AddInt32(&c.sleepCount, 1)
-	if d <= 0 {
-		return nil
+	handler, err := c.sleep(ctx, d, c.origin(ctx, "sleep"))
+	handler.resume()
+	return err
+}
+
+// sleep blocks like SleepWithContext and returns the handler it registered,
+// if any, so that the caller can acknowledge its resumption.
+func (c *Clock) sleep(ctx context.Context, d time.Duration, from origin) (*sleepHandler, error) {
+	return c.sleepUntil(ctx, c.Now().Add(d), from)
+}
+
+// sleepUntil is like sleep, but waits for an absolute deadline.
+func (c *Clock) sleepUntil(ctx context.Context, deadline time.Time, from origin) (*sleepHandler, error) {
+	return c.register(deadline, from).wait(ctx)
+}
+
+// pendingWait is a wait registered on a clock, that a goroutine can block on
+// later. Registering before starting a goroutine makes the wait visible to the
+// clock as soon as it is created.
+type pendingWait struct {
+	clock      *Clock
+	key        uint64
+	registered bool
+	handler    *sleepHandler
+	done       chan struct{} // closed once the handler has fired
+	err        error         // why the handler fired, set before closing done
+}
+
+// register registers a wait until deadline.
+func (c *Clock) register(deadline time.Time, from origin) *pendingWait {
+	w := &pendingWait{clock: c, done: make(chan struct{})}
+	w.handler = &sleepHandler{
+		deadline: deadline,
+		origin:   from,
+		fire: func(err error) {
+			w.err = err
+			close(w.done)
+		},
 	}
-	ch := make(chan struct{})
-	handler := &sleepHandler{
-		c:        ch,
-		deadline: c.Now().Add(d),
+	w.handler.ack = make(chan struct{})
+	w.key, w.registered = c.schedule(w.handler)
+	return w
+}
+
+// wait blocks until the deadline of w is reached or ctx is done, like
+// sleepUntil.
+func (w *pendingWait) wait(ctx context.Context) (handler *sleepHandler, err error) {
+	deadline := w.handler.deadline
+	if !w.registered {
+		return nil, waitError(deadline, w.err)
+	}
+	if w.clock.labels {
+		doWithLabels(ctx, w.clock.profilerLabels(w.handler), func(ctx context.Context) {
+			handler, err = w.block(ctx)
+		})
+		return handler, err
 	}
-	c.handlers.Store(handlerID, handler)
+	return w.block(ctx)
+}
+
+// block blocks until w is released or ctx is done.
+func (w *pendingWait) block(ctx context.Context) (*sleepHandler, error) {
+	deadline := w.handler.deadline
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
-	case <-ch:
+		w.cancel(ctx.Err())
+		return w.handler, waitError(deadline, ctx.Err())
+	case <-w.done:
 	}
-	return nil
+	return w.handler, waitError(deadline, w.err)
 }
 
-// NewTimer creates a new clock-associated Timer that will send the current
-// time on its channel after at least duration d.
-func (c *Clock) NewTimer(d time.Duration) *Timer {
-	ctx, cancel := context.WithCancel(context.Background())
-	ch := make(chan time.Time, 1)
-	go func() {
-		defer close(ch)
-		err := c.SleepWithContext(ctx, d)
-		if err != nil {
-			return
-		}
-		ch <- c.Now()
-	}()
-	return &Timer{
-		C:      ch,
-		cancel: cancel,
+// cancel deregisters w, which nobody waits for anymore.
+func (w *pendingWait) cancel(err error) {
+	if w.registered {
+		w.clock.cancel(w.key, err)
+	}
+}
+
+// schedule registers handler, so that its fire function is called once the
+// clock reaches its deadline, or with an error if the wait is interrupted. If
+// the deadline is already due or the clock is closed, fire is called right
+// away and schedule returns false. Otherwise, it returns the key of the
+// handler.
+func (c *Clock) schedule(handler *sleepHandler) (uint64, bool) {
+	atomic.AddInt32(&c.sleepCount, 1)
+	c.statSleep()
+	if c.isClosed() {
+		handler.fire(ErrClockClosed)
+		return 0, false
+	}
+	now := c.Now()
+	if handler.deadline.Before(now) || handler.deadline.Equal(now) && c.policy == FireImmediately {
+		handler.fire(nil)
+		return 0, false
+	}
+	key := atomic.AddUint64(&c.lastID, 1)
+	handler.start = now
+	handler.id = key
+	c.emitWait(EventRegister, handler, nil)
+	c.waitersMu.Lock()
+	c.waits().push(handler)
+	n := c.addWaiters(1)
+	c.waitersMu.Unlock()
+	c.statRegister(n)
+	if c.isClosed() {
+		// Close may have missed the handler while scanning.
+		c.release(key, handler, ErrClockClosed)
+	}
+	return key, true
+}
+
+// cancel deregisters the handler stored under key, without calling its fire
+// function, because its wait was interrupted by err. It reports whether the
+// handler was still registered.
+func (c *Clock) cancel(key uint64, err error) bool {
+	handler, ok := c.removeHandler(key)
+	if !ok {
+		return false
+	}
+	c.statRelease(err)
+	c.emitWait(EventCancel, handler, err)
+	return true
+}
+
+// removeHandler deregisters the handler stored under key. It reports whether
+// the handler was still registered, so that concurrent removals (wake-up and
+// cancellation) are only accounted for once.
+func (c *Clock) removeHandler(key uint64) (*sleepHandler, bool) {
+	c.waitersMu.Lock()
+	defer c.waitersMu.Unlock()
+	handler, ok := c.waits().remove(key)
+	if !ok {
+		return nil, false
 	}
+	c.addWaiters(-1)
+	return handler, true
 }
 
-func (t *Timer) Stop() bool {
-	t.cancel()
+// release deregisters the handler stored under key and wakes its sleeper up
+// with err. It reports whether the handler was still registered.
+func (c *Clock) release(key uint64, handler *sleepHandler, err error) bool {
+	if _, ok := c.removeHandler(key); !ok {
+		return false
+	}
+	c.fire(handler, err)
 	return true
 }
+
+// fire wakes the sleeper of the deregistered handler up with err.
+func (c *Clock) fire(handler *sleepHandler, err error) {
+	c.statRelease(err)
+	if err == nil {
+		c.emitWait(EventFire, handler, nil)
+	} else {
+		c.emitWait(EventCancel, handler, err)
+	}
+	handler.fire(err)
+}
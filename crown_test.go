@@ -2,8 +2,11 @@ package crown
 
 import (
 	"context"
+	"errors"
+	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -11,7 +14,7 @@ import (
 func waitForSleepers(t *testing.T, clock *Clock, target, maxretry int) {
 	for try := 1; ; try++ {
 		time.Sleep(333 * time.Microsecond)
-		c := int(clock.GetSleepCount())
+		c := int(clock.Stats().Sleeps)
 		if c == target {
 			break
 		}
@@ -30,6 +33,88 @@ func TestClockSet(t *testing.T) {
 	}
 }
 
+func TestClockSetTime(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-04T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+	timer := clock.NewTimer(time.Hour)
+
+	clock.Set(refT.Add(time.Hour))
+	select {
+	case got := <-timer.C:
+		if want := refT.Add(time.Hour); got != want {
+			t.Errorf("Should fire at %q, got %q instead", want, got)
+		}
+	default:
+		t.Errorf("Set should fire the timers whose deadline is reached")
+	}
+
+	clock.ForwardTo(refT)
+	if got := clock.Now(); got != refT {
+		t.Errorf("Should move backward to %q, got %q instead", refT, got)
+	}
+
+	strict := NewClock(refT, WithStrictMonotonic())
+	defer func() {
+		if r := recover(); r != ErrNonMonotonic {
+			t.Errorf("Should panic with %v, got %v instead", ErrNonMonotonic, r)
+		}
+	}()
+	strict.Set(refT.Add(-time.Nanosecond))
+}
+
+func TestAdvanceToNext(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-04T09:00:00Z")
+	clock := NewClock(refT)
+	if _, ok := clock.NextDeadline(); ok {
+		t.Errorf("Should have no deadline without waiters")
+	}
+	if _, ok := clock.AdvanceToNext(); ok {
+		t.Errorf("Should not advance without waiters")
+	}
+	late := clock.NewTimer(time.Hour)
+	early := clock.NewTimer(time.Minute)
+
+	if next, ok := clock.NextDeadline(); !ok || next != refT.Add(time.Minute) {
+		t.Errorf("Next deadline should be %q, got %q", refT.Add(time.Minute), next)
+	}
+	if d, ok := clock.AdvanceToNext(); !ok || d != time.Minute {
+		t.Errorf("Should advance by %v, got %v", time.Minute, d)
+	}
+	<-early.C
+	if d, _ := clock.AdvanceToNext(); d != 59*time.Minute {
+		t.Errorf("Should advance by %v, got %v", 59*time.Minute, d)
+	}
+	if got, want := <-late.C, refT.Add(time.Hour); got != want {
+		t.Errorf("Should fire at %q, got %q instead", want, got)
+	}
+}
+
+func TestRunUntilIdle(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-04T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+	if d := clock.RunUntilIdle(); d != 0 {
+		t.Errorf("Should not move an idle clock, got %v", d)
+	}
+
+	// A retry loop with exponential backoff: 1s, 2s, 4s, 8s.
+	done := make(chan int)
+	go func() {
+		attempts := 1
+		for backoff := time.Second; backoff <= 8*time.Second; backoff *= 2 {
+			clock.Sleep(backoff)
+			attempts++
+		}
+		done <- attempts
+	}()
+	clock.BlockUntil(1)
+	if d := clock.RunUntilIdle(); d != 15*time.Second {
+		t.Errorf("Should move by %v, got %v", 15*time.Second, d)
+	}
+	if attempts := <-done; attempts != 5 {
+		t.Errorf("Should make 5 attempts, got %d", attempts)
+	}
+}
+
 func TestClockForward(t *testing.T) {
 	refT, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
 	clock := NewClock(refT)
@@ -42,6 +127,80 @@ func TestClockForward(t *testing.T) {
 	}
 }
 
+func TestClockSinceUntil(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2006-01-02T15:04:05Z")
+	clock := NewClock(refT)
+	clock.Forward(2 * time.Second)
+	if got := clock.Since(refT); got != 2*time.Second {
+		t.Errorf("Since should be %v, got %v instead", 2*time.Second, got)
+	}
+	if got := clock.Until(refT.Add(5 * time.Second)); got != 3*time.Second {
+		t.Errorf("Until should be %v, got %v instead", 3*time.Second, got)
+	}
+	if got := clock.Until(refT); got != -2*time.Second {
+		t.Errorf("Until a past time should be %v, got %v instead", -2*time.Second, got)
+	}
+}
+
+func TestWaitersAndIDs(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-03T09:00:00Z")
+	clock := NewClock(refT)
+	clock.NewTimer(0)
+	clock.NewTimer(time.Second).Stop()
+	timer := clock.NewTimer(time.Second)
+	defer timer.Stop()
+
+	if got := clock.GetSleepCount(); got != 3 {
+		t.Errorf("Should count 3 waits started, got %d", got)
+	}
+	clock.BlockUntil(1)
+	pending := clock.Pending()
+	if len(pending) != 1 || pending[0].ID != 2 {
+		t.Errorf("Should have wait 2 pending, the first registered one being stopped, got %+v", pending)
+	}
+}
+
+func TestBlockUntil(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-03T09:00:00Z")
+	clock := NewClock(refT)
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			clock.Sleep(time.Second)
+			done <- struct{}{}
+		}()
+	}
+	clock.BlockUntil(3)
+	clock.Forward(time.Second)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+	clock.BlockUntil(0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := clock.BlockUntilContext(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("Should fail with DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestForwardN(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-03T09:00:00Z")
+	clock := NewClock(refT)
+	for _, d := range []time.Duration{time.Second, 2 * time.Second, 2 * time.Second, time.Minute} {
+		defer clock.NewTimer(d).Stop()
+	}
+	if n := clock.ForwardN(time.Second); n != 1 {
+		t.Errorf("Should release 1 timer, got %d", n)
+	}
+	if n := clock.ForwardN(10 * time.Second); n != 2 {
+		t.Errorf("Should release 2 timers, got %d", n)
+	}
+	if n := clock.ForwardN(time.Second); n != 0 {
+		t.Errorf("Should release no timer, got %d", n)
+	}
+}
+
 func TestSleep(t *testing.T) {
 	refT, _ := time.Parse(time.RFC3339, "2022-11-25T01:00:00Z")
 	clock := NewClock(refT)
@@ -137,7 +296,8 @@ func TestTimer(t *testing.T) {
 		select {
 		case <-timer.C:
 		case <-time.After(time.Second):
-			t.Fatalf("Did not return. t=%q", clock.Now())
+			t.Errorf("Did not return. t=%q", clock.Now())
+			return
 		}
 		now := clock.Now()
 		if now.Before(target) {
@@ -163,7 +323,7 @@ func TestTimerStop(t *testing.T) {
 		select {
 		case _, ok = <-timer.C:
 		case <-time.After(time.Second):
-			t.Fatalf("Did not return after 1 sec")
+			t.Errorf("Did not return after 1 sec")
 		}
 		if ok {
 			t.Error("Timer has been fired despite Stop() call.", ok)
@@ -235,3 +395,556 @@ func TestConcurrentSleepers(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestSleepWithContextDeregisters(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-01T09:00:00Z")
+	clock := NewClock(refT)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		clock.SleepWithContext(ctx, 42*time.Second)
+		close(done)
+	}()
+
+	waitForSleepers(t, clock, 1, 10)
+	if got := clock.Waiters(); got != 1 {
+		t.Fatalf("Should have 1 waiter, got %d instead", got)
+	}
+
+	cancel()
+	<-done
+	if got := clock.Waiters(); got != 0 {
+		t.Errorf("Should have 0 waiter after cancellation, got %d instead", got)
+	}
+}
+
+func TestPendingTimers(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-01T09:00:00Z")
+	clock := NewClock(refT)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			clock.SleepWithContext(ctx, time.Hour)
+		}
+		close(done)
+	}()
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(time.Minute)
+	clock.BlockUntil(3)
+	if got := clock.PendingTimers(); got != 2 {
+		t.Errorf("Should have 2 pending timers, got %d instead", got)
+	}
+
+	// Canceled sleeps and stopped timers must not stay registered.
+	cancel()
+	<-done
+	timer.Stop()
+	ticker.Stop()
+	if got := clock.PendingTimers(); got != 0 {
+		t.Errorf("Should have 0 pending timer after Stop(), got %d instead", got)
+	}
+	if got := clock.Waiters(); got != 0 {
+		t.Errorf("Should have 0 waiter, got %d instead", got)
+	}
+	if got := len(clock.Pending()); got != 0 {
+		t.Errorf("Should have 0 pending wait, got %d instead", got)
+	}
+}
+
+func TestTimerStopReturnValue(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+
+	timer := clock.NewTimer(42 * time.Second)
+	waitForSleepers(t, clock, 1, 10)
+	if !timer.Stop() {
+		t.Errorf("First Stop() on a pending timer should return true")
+	}
+	if timer.Stop() {
+		t.Errorf("Stop() on a stopped timer should return false")
+	}
+
+	timer = clock.NewTimer(42 * time.Second)
+	waitForSleepers(t, clock, 2, 10)
+	clock.Forward(42 * time.Second)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatalf("Did not fire after 1 sec. t=%q", clock.Now())
+	}
+	if timer.Stop() {
+		t.Errorf("Stop() on a fired timer should return false")
+	}
+}
+
+func TestZeroClock(t *testing.T) {
+	var s struct {
+		clock Clock
+	}
+	clock := &s.clock
+	if got := clock.Now(); !got.IsZero() {
+		t.Errorf("Should start at the zero time, got %q instead", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(2 * time.Second)
+		close(done)
+	}()
+	timer := clock.NewTimer(3 * time.Second)
+	waitForSleepers(t, clock, 2, 10)
+
+	clock.Forward(3 * time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Sleeper did not return after clock has reached t+2s. t=%q", clock.Now())
+	}
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatalf("Timer did not fire after clock has reached t+3s. t=%q", clock.Now())
+	}
+}
+
+func TestTimerState(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+
+	fired := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	waitForSleepers(t, clock, 2, 10)
+	if got := fired.State(); got != TimerPending {
+		t.Errorf("Should be %v, got %v instead", TimerPending, got)
+	}
+
+	stopped.Stop()
+	clock.Forward(time.Second)
+	<-fired.C
+	if got := fired.State(); got != TimerFired {
+		t.Errorf("Should be %v, got %v instead", TimerFired, got)
+	}
+	if got := stopped.State(); got != TimerStopped {
+		t.Errorf("Should be %v, got %v instead", TimerStopped, got)
+	}
+}
+
+func TestForwardWithWakeAck(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+
+	timer := clock.NewTimer(5 * time.Second)
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(5 * time.Second)
+		close(done)
+	}()
+	waitForSleepers(t, clock, 2, 10)
+
+	clock.Forward(5 * time.Second)
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer value should be available as soon as Forward returns")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Sleeper did not return after clock has reached t+5s. t=%q", clock.Now())
+	}
+}
+
+func TestForwardAndWait(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-05T09:00:00Z")
+	clock := NewClock(refT)
+	var resumed int32
+	for i := 0; i < 10; i++ {
+		go func() {
+			clock.Sleep(time.Second)
+			atomic.AddInt32(&resumed, 1)
+		}()
+	}
+	timer := clock.NewTimer(time.Second)
+	clock.BlockUntil(11)
+	clock.ForwardAndWait(time.Second)
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer should have fired once ForwardAndWait returns")
+	}
+	// Sleep has returned, but the goroutines may not have run any further.
+	for atomic.LoadInt32(&resumed) != 10 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStrictMonotonic(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithStrictMonotonic())
+	clock.Forward(time.Second)
+
+	defer func() {
+		if r := recover(); r != ErrNonMonotonic {
+			t.Errorf("Should panic with %v, got %v instead", ErrNonMonotonic, r)
+		}
+		if got, want := clock.Now(), refT.Add(time.Second); got != want {
+			t.Errorf("Should be %q, got %q instead", want, got)
+		}
+	}()
+	clock.Forward(-time.Second)
+}
+
+func TestTimerConcurrentStop(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+
+	N := 50
+	for round := 0; round < 20; round++ {
+		timer := clock.NewTimer(time.Second)
+		var stopped int32
+		var wg sync.WaitGroup
+		wg.Add(N)
+		for i := 0; i < N; i++ {
+			go func() {
+				defer wg.Done()
+				if timer.Stop() {
+					atomic.AddInt32(&stopped, 1)
+				}
+			}()
+		}
+		if round%2 == 0 {
+			clock.Forward(time.Second)
+		}
+		wg.Wait()
+
+		want := int32(1)
+		if timer.State() == TimerFired {
+			want = 0
+		}
+		if stopped != want {
+			t.Errorf("Round %d: %d Stop() calls returned true, want %d", round, stopped, want)
+		}
+		if timer.Stop() {
+			t.Errorf("Round %d: Stop() returned true after the timer was %v", round, timer.State())
+		}
+	}
+}
+
+func TestCloseReleasesWaiters(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+
+	slept := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Minute)
+		close(slept)
+	}()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- clock.SleepWithContext(context.Background(), time.Minute)
+	}()
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(time.Minute)
+	waitForSleepers(t, clock, 4, 10)
+
+	clock.Close()
+
+	select {
+	case <-slept:
+	case <-time.After(time.Second):
+		t.Errorf("Sleep did not return after 1 sec")
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrClockClosed) {
+			t.Errorf("Should be %v, got %v instead", ErrClockClosed, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("SleepWithContext did not return after 1 sec")
+	}
+	for name, c := range map[string]<-chan time.Time{"Timer": timer.C, "Ticker": ticker.C} {
+		select {
+		case _, ok := <-c:
+			if ok {
+				t.Errorf("%s has fired despite Close() call", name)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s channel was not closed after 1 sec", name)
+		}
+	}
+	if got := timer.State(); got != TimerStopped {
+		t.Errorf("Should be %v, got %v instead", TimerStopped, got)
+	}
+	if got := clock.Waiters(); got != 0 {
+		t.Errorf("Should have 0 waiter after Close, got %d instead", got)
+	}
+}
+
+func TestClosedClockFailsFast(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+	clock.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.Sleep(time.Minute)
+		if err := clock.SleepWithContext(context.Background(), time.Minute); !errors.Is(err, ErrClockClosed) {
+			t.Errorf("Should be %v, got %v instead", ErrClockClosed, err)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Sleep calls did not return after 1 sec")
+	}
+
+	timer := clock.NewTimer(time.Minute)
+	if got := timer.State(); got != TimerStopped {
+		t.Errorf("Should be %v, got %v instead", TimerStopped, got)
+	}
+	if _, ok := <-timer.C; ok {
+		t.Errorf("Timer channel should be closed")
+	}
+	if _, ok := <-clock.NewTicker(time.Minute).C; ok {
+		t.Errorf("Ticker channel should be closed")
+	}
+	if got := clock.Waiters(); got != 0 {
+		t.Errorf("Should have 0 waiter, got %d instead", got)
+	}
+}
+
+func TestDeadlinePolicyWaitForAdvance(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-11-25T01:00:00Z")
+	clock := NewClock(refT, WithDeadlinePolicy(WaitForAdvance))
+
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(0)
+		close(done)
+	}()
+	waitForSleepers(t, clock, 1, 10)
+	select {
+	case <-done:
+		t.Fatalf("Sleep(0) returned before the clock advanced")
+	default:
+	}
+
+	clock.Sleep(-time.Second) // Past deadlines still return immediately.
+
+	clock.Forward(0)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Sleep(0) did not return after Forward(0)")
+	}
+}
+
+func TestStdChannels(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithStdChannels(), WithWakeAck())
+
+	fired := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	waitForSleepers(t, clock, 2, 10)
+	stopped.Stop()
+	clock.Forward(time.Second)
+	<-fired.C
+
+	// Let the timer goroutines terminate.
+	time.Sleep(10 * time.Millisecond)
+	for _, timer := range []*Timer{fired, stopped} {
+		select {
+		case _, ok := <-timer.C:
+			t.Errorf("Timer channel should stay open and empty, got ok=%v", ok)
+		default:
+		}
+	}
+}
+
+func TestCancelAll(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- clock.SleepWithContext(context.Background(), time.Minute)
+	}()
+	timer := clock.NewTimer(time.Minute)
+	waitForSleepers(t, clock, 2, 10)
+
+	if n := clock.CancelAll(); n != 2 {
+		t.Errorf("Should have canceled 2 waits, got %d instead", n)
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrCanceled) {
+			t.Errorf("Should be %v, got %v instead", ErrCanceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("SleepWithContext did not return after 1 sec")
+	}
+	if _, ok := <-timer.C; ok {
+		t.Errorf("Timer has fired despite CancelAll() call")
+	}
+
+	// The clock is still usable.
+	timer = clock.NewTimer(time.Minute)
+	waitForSleepers(t, clock, 3, 10)
+	clock.Forward(time.Minute)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Errorf("Timer did not fire after CancelAll. t=%q", clock.Now())
+	}
+}
+
+func TestSleepWithContextErrors(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	tests := []struct {
+		ctx  context.Context
+		want error
+	}{
+		{canceled, context.Canceled},
+		{expired, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		err := clock.SleepWithContext(tt.ctx, time.Minute)
+		var werr *WaitError
+		if !errors.As(err, &werr) {
+			t.Fatalf("Should be a *WaitError, got %T instead", err)
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Should wrap %v, got %v instead", tt.want, err)
+		}
+		if want := refT.Add(time.Minute); werr.Deadline != want {
+			t.Errorf("Deadline should be %q, got %q instead", want, werr.Deadline)
+		}
+	}
+}
+
+func TestStepwiseForward(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-05T09:00:00Z")
+	clock := NewClock(refT, WithStepwiseForward(), WithWakeAck())
+	timers := []*Timer{clock.NewTimer(10 * time.Second), clock.NewTimer(5 * time.Second)}
+	woke := make(chan time.Time, 1)
+	go func() {
+		clock.Sleep(20 * time.Second)
+		woke <- clock.Now()
+	}()
+	clock.BlockUntil(3)
+
+	if n := clock.ForwardN(30 * time.Second); n != 3 {
+		t.Errorf("Should release 3 waits, got %d", n)
+	}
+	for i, want := range []time.Duration{10 * time.Second, 5 * time.Second} {
+		if got := <-timers[i].C; got != refT.Add(want) {
+			t.Errorf("Timer %d should fire at %q, got %q instead", i, refT.Add(want), got)
+		}
+	}
+	if got, want := <-woke, refT.Add(20*time.Second); got.Before(want) {
+		t.Errorf("Sleeper should wake up at %q, got %q", want, got)
+	}
+	if got, want := clock.Now(), refT.Add(30*time.Second); got != want {
+		t.Errorf("Should end at %q, got %q instead", want, got)
+	}
+	if got := clock.Stats().Advances; got != 4 {
+		t.Errorf("Should stop 3 times before the end, got %d advances", got)
+	}
+}
+
+func TestFiringOrder(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-05T09:00:00Z")
+	clock := NewClock(refT)
+	var order, want []int
+	for i := 0; i < 20; i++ {
+		i := i
+		d := time.Second
+		if i%2 == 1 {
+			d = 500 * time.Millisecond
+			want = append(want, i)
+		}
+		clock.schedule(&sleepHandler{
+			deadline: refT.Add(d),
+			fire:     func(error) { order = append(order, i) },
+		})
+	}
+	for i := 0; i < 20; i += 2 {
+		want = append(want, i)
+	}
+	clock.Forward(time.Second)
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Should fire in order %v, got %v", want, order)
+		}
+	}
+}
+
+func TestIDOverflow(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-05T09:00:00Z")
+	// Start right below the limits of 32-bit counters, as long-running
+	// sessions would reach them after billions of waits.
+	for _, last := range []uint64{math.MaxInt32 - 50, math.MaxUint32 - 50} {
+		for _, opts := range [][]Option{nil, {WithTimerWheel(time.Millisecond)}} {
+			clock := NewClock(refT, opts...)
+			clock.lastID = last
+			var order []int
+			keys := make([]uint64, 100)
+			for i := range keys {
+				i := i
+				keys[i], _ = clock.schedule(&sleepHandler{
+					deadline: refT.Add(time.Second),
+					fire: func(err error) {
+						if err == nil {
+							order = append(order, i)
+						}
+					},
+				})
+			}
+			if keys[99] != last+100 {
+				t.Errorf("Should give key %d to the last wait, got %d", last+100, keys[99])
+			}
+			for i := 0; i < len(keys); i += 3 {
+				if !clock.cancel(keys[i], ErrCanceled) {
+					t.Errorf("Should cancel wait %d", i)
+				}
+			}
+			if got := clock.Waiters(); got != 66 {
+				t.Errorf("Should have 66 waiters, got %d", got)
+			}
+			if n := clock.ForwardN(time.Second); n != 66 {
+				t.Errorf("Should release 66 waits, got %d", n)
+			}
+			for i, got := range order {
+				if want := i + i/2 + 1; got != want || len(order) != 66 {
+					t.Fatalf("Should fire in registration order, got %v", order)
+				}
+			}
+		}
+	}
+}
+
+func TestWithLocation(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-23T09:00:00+01:00")
+	tokyo := time.FixedZone("JST", 9*3600)
+	clock := NewClock(refT, WithLocation(tokyo))
+	if got := clock.Now(); got.Location() != tokyo || !got.Equal(refT) {
+		t.Errorf("Now should be %s in JST, got %s", refT, got)
+	}
+	timer := clock.NewTimer(time.Hour)
+	clock.Set(refT.UTC().Add(time.Hour))
+	if got := <-timer.C; got.Location() != tokyo || got.Format(time.Kitchen) != "6:00PM" {
+		t.Errorf("Timer should send 6:00PM JST, got %s", got)
+	}
+	if got := clock.NowIn(time.UTC); got.Location() != time.UTC || got.Hour() != 9 {
+		t.Errorf("NowIn should be 9:00 UTC, got %s", got)
+	}
+}
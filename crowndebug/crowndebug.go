@@ -0,0 +1,80 @@
+// Package crowndebug provides an HTTP handler exposing the state of a crown
+// clock, to inspect long-running simulations interactively.
+//
+// The handler can be mounted on a test server or on a debug port:
+//
+//	http.Handle("/debug/clock", crowndebug.Handler(clock))
+package crowndebug
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// State is the state of a clock, as rendered by the handler.
+type State struct {
+	Now     time.Time           `json:"now"`
+	Pending []crown.PendingWait `json:"pending"`
+	Stats   crown.Stats         `json:"stats"`
+}
+
+// Snapshot returns the current state of c.
+func Snapshot(c *crown.Clock) State {
+	return State{
+		Now:     c.Now(),
+		Pending: c.Pending(),
+		Stats:   c.Stats(),
+	}
+}
+
+// Handler returns an http.Handler rendering the current time of c, its
+// pending waits and its statistics. The state is rendered as JSON if the
+// request has the format=json query parameter or accepts application/json,
+// and as an HTML page otherwise.
+func Handler(c *crown.Clock) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := Snapshot(c)
+		if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			enc.Encode(state)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.Execute(w, state)
+	})
+}
+
+var page = template.Must(template.New("clock").Funcs(template.FuncMap{
+	"format": func(t time.Time) string { return t.Format(time.RFC3339Nano) },
+	"sub":    func(t, u time.Time) time.Duration { return t.Sub(u) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>crown clock</title></head>
+<body>
+<h1>Clock at {{format .Now}}</h1>
+<h2>Statistics</h2>
+<table>
+<tr><td>Waiters</td><td>{{.Stats.Waiters}} (max {{.Stats.MaxWaiters}})</td></tr>
+<tr><td>Sleeps</td><td>{{.Stats.Sleeps}}</td></tr>
+<tr><td>Fires</td><td>{{.Stats.Fires}}</td></tr>
+<tr><td>Cancellations</td><td>{{.Stats.Cancellations}}</td></tr>
+<tr><td>Advances</td><td>{{.Stats.Advances}} (last {{.Stats.LastAdvance}})</td></tr>
+</table>
+<h2>{{len .Pending}} pending wait(s)</h2>
+<table>
+<tr><th>Deadline</th><th>Due in</th><th>Registered at</th><th>Stack</th></tr>
+{{- $now := .Now}}
+{{- range .Pending}}
+<tr><td>{{format .Deadline}}</td><td>{{sub .Deadline $now}}</td><td>{{format .Start}}</td><td><pre>{{.Stack}}</pre></td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,45 @@
+package crowndebug
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestHandler(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-15T09:00:00Z")
+	clock := crown.NewClock(refT)
+	timer := clock.NewTimer(time.Minute)
+	defer timer.Stop()
+	clock.Forward(time.Second)
+	handler := Handler(clock)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/?format=json", nil))
+	var state State
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("Should render JSON, got %v:\n%s", err, rec.Body)
+	}
+	if want := refT.Add(time.Second); !state.Now.Equal(want) {
+		t.Errorf("Should render the time %q, got %q", want, state.Now)
+	}
+	if len(state.Pending) != 1 || !state.Pending[0].Deadline.Equal(refT.Add(time.Minute)) {
+		t.Errorf("Should render the pending timer, got %+v", state.Pending)
+	}
+	if state.Stats.Advances != 1 {
+		t.Errorf("Should render the stats, got %+v", state.Stats)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"Clock at 2022-12-15T09:00:01Z", "1 pending wait(s)", "2022-12-15T09:01:00Z", "59s"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTML page should contain %q, got:\n%s", want, body)
+		}
+	}
+}
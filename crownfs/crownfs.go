@@ -0,0 +1,114 @@
+// Package crownfs provides an in-memory file system, built on testing/fstest,
+// whose modification times come from a crown clock: writes stamp the files
+// with the current time of the clock. It is meant to test cache invalidation
+// and cleanup logic based on the age of files by advancing the clock.
+package crownfs
+
+import (
+	"io/fs"
+	"sync"
+	"testing/fstest"
+
+	"github.com/enzzc/crown"
+)
+
+// FS is an in-memory file system timed by a clock. It implements fs.FS,
+// fs.ReadFileFS, fs.ReadDirFS and fs.StatFS, and is safe for concurrent use:
+// files opened before a write keep their former content.
+type FS struct {
+	clock *crown.Clock
+	mu    sync.RWMutex
+	files fstest.MapFS
+}
+
+// New returns a file system timed by c, holding a copy of files. The files
+// without a modification time are stamped with the current time of c.
+func New(c *crown.Clock, files fstest.MapFS) *FS {
+	fsys := &FS{clock: c, files: make(fstest.MapFS, len(files))}
+	now := c.Now()
+	for name, f := range files {
+		f := *f
+		if f.ModTime.IsZero() {
+			f.ModTime = now
+		}
+		fsys.files[name] = &f
+	}
+	return fsys
+}
+
+// WriteFile writes data to the file name, creating it with permissions perm
+// if needed, and stamps it with the current time of the clock.
+func (fsys *FS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if f, ok := fsys.files[name]; ok {
+		if f.Mode.IsDir() {
+			return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+		}
+		perm = f.Mode
+	}
+	// Files are replaced rather than modified, since opened files refer to
+	// them.
+	fsys.files[name] = &fstest.MapFile{
+		Data:    append([]byte(nil), data...),
+		Mode:    perm,
+		ModTime: fsys.clock.Now(),
+	}
+	return nil
+}
+
+// Touch stamps the file name with the current time of the clock.
+func (fsys *FS) Touch(name string) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	f, ok := fsys.files[name]
+	if !ok {
+		return &fs.PathError{Op: "touch", Path: name, Err: fs.ErrNotExist}
+	}
+	touched := *f
+	touched.ModTime = fsys.clock.Now()
+	fsys.files[name] = &touched
+	return nil
+}
+
+// Remove removes the file name.
+func (fsys *FS) Remove(name string) error {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if _, ok := fsys.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(fsys.files, name)
+	return nil
+}
+
+// Open implements fs.FS.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+	return fsys.files.Open(name)
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (fsys *FS) ReadFile(name string) ([]byte, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+	return fsys.files.ReadFile(name)
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+	return fsys.files.ReadDir(name)
+}
+
+// Stat implements fs.StatFS.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	fsys.mu.RLock()
+	defer fsys.mu.RUnlock()
+	return fsys.files.Stat(name)
+}
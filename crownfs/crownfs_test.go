@@ -0,0 +1,60 @@
+package crownfs
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestFS(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-22T09:00:00Z")
+	clock := crown.NewClock(refT)
+	fsys := New(clock, fstest.MapFS{
+		"cache/old.json": {Data: []byte("{}")},
+	})
+	clock.Forward(2 * time.Hour)
+	if err := fsys.WriteFile("cache/new.json", []byte(`{"fresh":true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "cache/old.json", "cache/new.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the files older than an hour.
+	clock.Forward(30 * time.Minute)
+	entries, err := fs.ReadDir(fsys, "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clock.Now().Sub(info.ModTime()) > time.Hour {
+			fsys.Remove("cache/" + e.Name())
+		}
+	}
+	if _, err := fs.Stat(fsys, "cache/old.json"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("old.json should have been removed, got %v", err)
+	}
+	info, err := fs.Stat(fsys, "cache/new.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := refT.Add(2 * time.Hour); !info.ModTime().Equal(want) {
+		t.Errorf("new.json should be stamped %q, got %q", want, info.ModTime())
+	}
+
+	if err := fsys.Touch("cache/new.json"); err != nil {
+		t.Fatal(err)
+	}
+	info, _ = fs.Stat(fsys, "cache/new.json")
+	if !info.ModTime().Equal(clock.Now()) {
+		t.Errorf("Touch should stamp %q, got %q", clock.Now(), info.ModTime())
+	}
+}
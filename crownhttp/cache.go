@@ -0,0 +1,105 @@
+package crownhttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// StampDate sets the Date header of h to the current time of the clock c.
+func StampDate(c *crown.Clock, h http.Header) {
+	h.Set("Date", c.Now().UTC().Format(http.TimeFormat))
+}
+
+// DateHandler returns a handler stamping the Date header of the responses of
+// h with the time of the clock c, in place of the real time set by the
+// server.
+func DateHandler(c *crown.Clock, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		StampDate(c, w.Header())
+		h.ServeHTTP(w, r)
+	})
+}
+
+// cacheControl parses the directives of the Cache-Control header of h.
+func cacheControl(h http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, line := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name != "" {
+				directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// FreshnessLifetime returns how long a response with the header h stays
+// fresh in a private cache, following RFC 9111: the max-age directive of
+// Cache-Control, or else the difference between its Expires and Date
+// headers. It reports false if the header does not tell.
+func FreshnessLifetime(h http.Header) (time.Duration, bool) {
+	if v, ok := cacheControl(h)["max-age"]; ok {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	expires, err := http.ParseTime(h.Get("Expires"))
+	if err != nil {
+		return 0, h.Get("Expires") != "" // an invalid Expires means already expired
+	}
+	date, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		return 0, false
+	}
+	return expires.Sub(date), true
+}
+
+// Age returns the current age, as defined by RFC 9111, at the time of the
+// clock c, of a response with the header h received at responseTime.
+func Age(c *crown.Clock, h http.Header, responseTime time.Time) time.Duration {
+	var age time.Duration
+	if date, err := http.ParseTime(h.Get("Date")); err == nil && responseTime.After(date) {
+		age = responseTime.Sub(date)
+	}
+	if secs, err := strconv.ParseInt(h.Get("Age"), 10, 64); err == nil && time.Duration(secs)*time.Second > age {
+		age = time.Duration(secs) * time.Second
+	}
+	return age + c.Now().Sub(responseTime)
+}
+
+// IsFresh reports whether a response with the header h, received at
+// responseTime, can be served from a private cache without revalidation at
+// the time of the clock c. Responses with the no-store or no-cache
+// directives, or without freshness information, are never fresh.
+func IsFresh(c *crown.Clock, h http.Header, responseTime time.Time) bool {
+	cc := cacheControl(h)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	lifetime, ok := FreshnessLifetime(h)
+	return ok && lifetime > Age(c, h, responseTime)
+}
+
+// CookieExpired reports whether the cookie ck, received at the given time,
+// has expired at the time of the clock c. Max-Age takes precedence over
+// Expires; session cookies, with neither, never expire.
+func CookieExpired(c *crown.Clock, ck *http.Cookie, received time.Time) bool {
+	now := c.Now()
+	switch {
+	case ck.MaxAge < 0:
+		return true
+	case ck.MaxAge > 0:
+		return !now.Before(received.Add(time.Duration(ck.MaxAge) * time.Second))
+	case !ck.Expires.IsZero():
+		return !now.Before(ck.Expires)
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package crownhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestIsFresh(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-25T09:00:00Z")
+	clock := crown.NewClock(refT)
+	handler := DateHandler(clock, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	h := rec.Header()
+	if got := h.Get("Date"); got != "Sun, 25 Dec 2022 09:00:00 GMT" {
+		t.Errorf("Should stamp the clock time, got %q", got)
+	}
+
+	received := clock.Now()
+	clock.Forward(59 * time.Minute)
+	if !IsFresh(clock, h, received) {
+		t.Errorf("Response should be fresh for an hour")
+	}
+	clock.Forward(time.Minute)
+	if IsFresh(clock, h, received) {
+		t.Errorf("Response should be stale after an hour")
+	}
+
+	expires := http.Header{}
+	StampDate(clock, expires)
+	expires.Set("Expires", clock.Now().Add(10*time.Minute).UTC().Format(http.TimeFormat))
+	expires.Set("Age", "300")
+	if got, ok := FreshnessLifetime(expires); !ok || got != 10*time.Minute {
+		t.Errorf("Should derive a 10m lifetime from Expires, got %v %v", got, ok)
+	}
+	received = clock.Now()
+	clock.Forward(4 * time.Minute)
+	if !IsFresh(clock, expires, received) {
+		t.Errorf("Response aged 9m should be fresh")
+	}
+	clock.Forward(time.Minute)
+	if IsFresh(clock, expires, received) {
+		t.Errorf("Response aged 10m should be stale")
+	}
+}
+
+func TestCookieExpired(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-25T09:00:00Z")
+	clock := crown.NewClock(refT)
+	received := clock.Now()
+	maxAge := &http.Cookie{Name: "a", MaxAge: 60, Expires: refT.Add(time.Hour)}
+	expires := &http.Cookie{Name: "b", Expires: refT.Add(time.Hour)}
+	session := &http.Cookie{Name: "c"}
+
+	clock.Forward(time.Minute)
+	if !CookieExpired(clock, maxAge, received) || CookieExpired(clock, expires, received) {
+		t.Errorf("Max-Age should take precedence over Expires")
+	}
+	clock.Forward(time.Hour)
+	if !CookieExpired(clock, expires, received) || CookieExpired(clock, session, received) {
+		t.Errorf("Expires should apply, session cookies should not expire")
+	}
+	if !CookieExpired(clock, &http.Cookie{Name: "d", MaxAge: -1}, received) {
+		t.Errorf("Negative Max-Age should expire the cookie")
+	}
+}
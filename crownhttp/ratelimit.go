@@ -0,0 +1,116 @@
+package crownhttp
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// RateLimit returns a handler limiting the requests of each client to h with
+// token buckets refilled by the clock c: a client can make burst requests at
+// once, and then one request every interval of clock time. The requests over
+// the limit are rejected with a 429 Too Many Requests error, and a
+// Retry-After header telling, in seconds of clock time, when the next request
+// will be allowed.
+//
+// Clients are identified by key, or by the host of the remote address of
+// their requests if key is nil. RateLimit panics if interval or burst is not
+// positive.
+func RateLimit(c *crown.Clock, h http.Handler, interval time.Duration, burst int, key func(*http.Request) string) http.Handler {
+	if interval <= 0 {
+		panic("crownhttp: non-positive interval for RateLimit")
+	}
+	if burst <= 0 {
+		panic("crownhttp: non-positive burst for RateLimit")
+	}
+	if key == nil {
+		key = remoteHost
+	}
+	return &rateLimiter{
+		clock:    c,
+		handler:  h,
+		interval: interval,
+		burst:    float64(burst),
+		key:      key,
+		buckets:  make(map[string]*bucket),
+	}
+}
+
+type rateLimiter struct {
+	clock    *crown.Clock
+	handler  http.Handler
+	interval time.Duration
+	burst    float64
+	key      func(*http.Request) string
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was computed
+}
+
+func (l *rateLimiter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if wait, ok := l.reserve(l.key(r)); !ok {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+		http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	l.handler.ServeHTTP(w, r)
+}
+
+// reserve takes a token from the bucket of client, or returns how long to
+// wait for the next one.
+func (l *rateLimiter) reserve(client string) (time.Duration, bool) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.buckets[client]
+	if b == nil {
+		l.prune(now)
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.refill(now, l.interval, l.burst)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) * float64(l.interval)), false
+}
+
+func (b *bucket) refill(now time.Time, interval time.Duration, burst float64) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+float64(elapsed)/float64(interval))
+	}
+	b.last = now
+}
+
+// prune forgets the clients whose buckets are full again, once there are
+// many of them.
+func (l *rateLimiter) prune(now time.Time) {
+	if len(l.buckets) < 1024 {
+		return
+	}
+	for client, b := range l.buckets {
+		if b.refill(now, l.interval, l.burst); b.tokens >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,64 @@
+package crownhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestRateLimit(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-26T09:00:00Z")
+	clock := crown.NewClock(refT)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := RateLimit(clock, ok, 10*time.Second, 2, nil)
+	get := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := get("10.0.0.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("Request %d should be allowed by the burst, got %d", i, rec.Code)
+		}
+	}
+	rec := get("10.0.0.1:5678")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "10" {
+		t.Errorf("Third request should be throttled for 10s, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := get("10.0.0.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("Other clients should not be throttled, got %d", rec.Code)
+	}
+
+	clock.Forward(7500 * time.Millisecond)
+	if rec := get("10.0.0.1:1234"); rec.Header().Get("Retry-After") != "3" {
+		t.Errorf("Should retry after 3s (2.5s rounded up), got %q", rec.Header().Get("Retry-After"))
+	}
+	clock.Forward(2500 * time.Millisecond)
+	if rec := get("10.0.0.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("Request should be allowed once a token is refilled, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitInvalid(t *testing.T) {
+	clock := crown.NewClock(time.Now())
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tc := range []struct {
+		interval time.Duration
+		burst    int
+	}{{0, 1}, {-time.Second, 1}, {time.Second, 0}, {time.Second, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RateLimit(%v, %d) should panic", tc.interval, tc.burst)
+				}
+			}()
+			RateLimit(clock, ok, tc.interval, tc.burst, nil)
+		}()
+	}
+}
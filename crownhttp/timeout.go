@@ -0,0 +1,130 @@
+// Package crownhttp provides HTTP middleware and helpers driven by a crown
+// clock, so that the time-dependent paths of servers and clients can be
+// unit-tested by advancing the clock.
+package crownhttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// TimeoutHandler is the equivalent of http.TimeoutHandler timed by the clock
+// c: it runs h with the given time limit in clock time. If the clock moves by
+// dt before h returns, the context of the request is canceled and the handler
+// responds with a 503 Service Unavailable error and the given message in its
+// body, or a default message if msg is empty. After such a timeout, writes by
+// h to its http.ResponseWriter return http.ErrHandlerTimeout.
+//
+// Like with http.TimeoutHandler, the response of h is buffered until it
+// returns, and its ResponseWriter does not support the Flusher and Hijacker
+// interfaces.
+func TimeoutHandler(c *crown.Clock, h http.Handler, dt time.Duration, msg string) http.Handler {
+	if msg == "" {
+		msg = "<html><head><title>Timeout</title></head><body><h1>Timeout</h1></body></html>"
+	}
+	return &timeoutHandler{clock: c, handler: h, dt: dt, body: msg}
+}
+
+type timeoutHandler struct {
+	clock   *crown.Clock
+	handler http.Handler
+	dt      time.Duration
+	body    string
+}
+
+func (h *timeoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	timer := h.clock.NewTimer(h.dt)
+	defer timer.Stop()
+	r = r.WithContext(ctx)
+
+	done := make(chan struct{})
+	panicked := make(chan any, 1)
+	tw := &timeoutWriter{w: w, h: make(http.Header)}
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked <- p
+			}
+		}()
+		h.handler.ServeHTTP(tw, r)
+		close(done)
+	}()
+	select {
+	case p := <-panicked:
+		panic(p)
+	case <-done:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		dst := w.Header()
+		for k, vv := range tw.h {
+			dst[k] = vv
+		}
+		if !tw.wroteHeader {
+			tw.code = http.StatusOK
+		}
+		w.WriteHeader(tw.code)
+		w.Write(tw.wbuf.Bytes())
+	case <-timer.C:
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, h.body)
+		tw.err = http.ErrHandlerTimeout
+	case <-r.Context().Done():
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		tw.err = r.Context().Err()
+	}
+}
+
+// timeoutWriter buffers the response of the handler.
+type timeoutWriter struct {
+	w    http.ResponseWriter
+	h    http.Header
+	wbuf bytes.Buffer
+
+	mu          sync.Mutex
+	err         error
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header { return tw.h }
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.err != nil {
+		return 0, tw.err
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.wbuf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.err != nil || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
@@ -0,0 +1,64 @@
+package crownhttp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestTimeoutHandler(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-21T09:00:00Z")
+	clock := crown.NewClock(refT)
+	writeErr := make(chan error, 1)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock.SleepWithContext(r.Context(), time.Minute)
+		_, err := w.Write([]byte("too late"))
+		writeErr <- err
+	})
+	handler := TimeoutHandler(clock, slow, 10*time.Second, "timed out")
+
+	rec := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		close(served)
+	}()
+	for clock.Waiters() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(10 * time.Second)
+	<-served
+
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "timed out" {
+		t.Errorf("Should respond 503 %q, got %d %q", "timed out", rec.Code, rec.Body.String())
+	}
+	if err := <-writeErr; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("Late writes should fail with ErrHandlerTimeout, got %v", err)
+	}
+}
+
+func TestTimeoutHandlerInTime(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-21T09:00:00Z")
+	clock := crown.NewClock(refT)
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Answer", "42")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	rec := httptest.NewRecorder()
+	TimeoutHandler(clock, fast, 10*time.Second, "").ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" || rec.Header().Get("X-Answer") != "42" {
+		t.Errorf("Should relay the response, got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	for try := 0; clock.Waiters() != 0; try++ {
+		if try == 100 {
+			t.Fatalf("Should stop its timer, got %d waiters", clock.Waiters())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
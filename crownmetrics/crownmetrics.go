@@ -0,0 +1,158 @@
+// Package crownmetrics provides a reference crown.MetricsSink which keeps the
+// metrics of clocks in memory and exposes them in the Prometheus text format.
+package crownmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds, in seconds, of the histogram buckets
+// used by default. They span from a millisecond to a day, since simulated
+// time tends to move by large steps.
+var DefaultBuckets = []float64{0.001, 0.01, 0.1, 1, 10, 60, 600, 3600, 86400}
+
+// Registry is a crown.MetricsSink keeping its metrics in memory. It is safe
+// for concurrent use, and serves its metrics over HTTP in the Prometheus text
+// format.
+type Registry struct {
+	mu         sync.Mutex
+	buckets    []float64
+	counters   map[string]float64
+	gauges     map[string]float64
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewRegistry returns an empty Registry whose histograms use the given
+// bucket upper bounds, or DefaultBuckets if none is given.
+func NewRegistry(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Registry{
+		buckets:    buckets,
+		counters:   make(map[string]float64),
+		gauges:     make(map[string]float64),
+		histograms: make(map[string]*histogram),
+	}
+}
+
+// AddCounter implements crown.MetricsSink.
+func (r *Registry) AddCounter(name string, delta float64) {
+	r.mu.Lock()
+	r.counters[name] += delta
+	r.mu.Unlock()
+}
+
+// SetGauge implements crown.MetricsSink.
+func (r *Registry) SetGauge(name string, value float64) {
+	r.mu.Lock()
+	r.gauges[name] = value
+	r.mu.Unlock()
+}
+
+// ObserveHistogram implements crown.MetricsSink.
+func (r *Registry) ObserveHistogram(name string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := r.histograms[name]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(r.buckets)+1)}
+		r.histograms[name] = h
+	}
+	h.counts[sort.SearchFloat64s(r.buckets, value)]++
+	h.sum += value
+	h.count++
+}
+
+// Counter returns the value of the counter name.
+func (r *Registry) Counter(name string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[name]
+}
+
+// Gauge returns the value of the gauge name.
+func (r *Registry) Gauge(name string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gauges[name]
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format, sorted by
+// name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cw := &countingWriter{w: w}
+	b := bufio.NewWriter(cw)
+	for _, name := range sortedKeys(r.counters) {
+		fmt.Fprintf(b, "# TYPE %s counter\n%s %s\n", name, name, formatFloat(r.counters[name]))
+	}
+	for _, name := range sortedKeys(r.gauges) {
+		fmt.Fprintf(b, "# TYPE %s gauge\n%s %s\n", name, name, formatFloat(r.gauges[name]))
+	}
+	for _, name := range sortedKeys(r.histograms) {
+		h := r.histograms[name]
+		fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+		var cumulative uint64
+		for i, count := range h.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(r.buckets) {
+				le = r.buckets[i]
+			}
+			fmt.Fprintf(b, "%s_bucket{le=%q} %d\n", name, formatFloat(le), cumulative)
+		}
+		fmt.Fprintf(b, "%s_sum %s\n%s_count %d\n", name, formatFloat(h.sum), name, h.count)
+	}
+	err := b.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
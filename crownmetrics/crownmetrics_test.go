@@ -0,0 +1,78 @@
+package crownmetrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestRegistry(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-17T09:00:00Z")
+	registry := NewRegistry(1, 60)
+	clock := crown.NewClock(refT, crown.WithWakeAck(), crown.WithMetrics(registry))
+	timer := clock.NewTimer(30 * time.Second)
+	pending := clock.NewTimer(time.Hour)
+	clock.Forward(time.Minute)
+	<-timer.C
+
+	if got := registry.Gauge(crown.MetricWaiters); got != 1 {
+		t.Errorf("Should have 1 waiter, got %v", got)
+	}
+	pending.Stop()
+	for clock.Waiters() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	var b strings.Builder
+	registry.WriteTo(&b)
+	want := `# TYPE crown_advanced_seconds_total counter
+crown_advanced_seconds_total 60
+# TYPE crown_advances_total counter
+crown_advances_total 1
+# TYPE crown_cancellations_total counter
+crown_cancellations_total 1
+# TYPE crown_fires_total counter
+crown_fires_total 1
+# TYPE crown_waits_total counter
+crown_waits_total 2
+# TYPE crown_waiters gauge
+crown_waiters 0
+# TYPE crown_advance_seconds histogram
+crown_advance_seconds_bucket{le="1"} 0
+crown_advance_seconds_bucket{le="60"} 1
+crown_advance_seconds_bucket{le="+Inf"} 1
+crown_advance_seconds_sum 60
+crown_advance_seconds_count 1
+# TYPE crown_wait_duration_seconds histogram
+crown_wait_duration_seconds_bucket{le="1"} 0
+crown_wait_duration_seconds_bucket{le="60"} 2
+crown_wait_duration_seconds_bucket{le="+Inf"} 2
+crown_wait_duration_seconds_sum 120
+crown_wait_duration_seconds_count 2
+`
+	if got := b.String(); got != want {
+		t.Errorf("Should write:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestWaitersGauge(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-17T09:00:00Z")
+	registry := NewRegistry()
+	clock := crown.NewClock(refT, crown.WithMetrics(registry))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.Sleep(time.Minute)
+	}()
+	clock.BlockUntil(1)
+	if got := registry.Gauge(crown.MetricWaiters); got != 1 {
+		t.Errorf("Should have 1 waiter once Sleep blocks, got %v", got)
+	}
+	clock.Forward(time.Minute)
+	<-done
+	if got := registry.Gauge(crown.MetricWaiters); got != 0 {
+		t.Errorf("Should have no waiter once Sleep returns, got %v", got)
+	}
+}
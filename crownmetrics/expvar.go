@@ -0,0 +1,17 @@
+package crownmetrics
+
+import (
+	"expvar"
+
+	"github.com/enzzc/crown"
+)
+
+// Publish publishes the statistics of c as the expvar variable name, so that
+// they are served in JSON by the /debug/vars handler of expvar along with the
+// other variables of the process. The statistics are read on every request.
+// Like expvar.Publish, Publish panics if name is already registered.
+func Publish(name string, c *crown.Clock) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Stats()
+	}))
+}
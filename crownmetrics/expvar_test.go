@@ -0,0 +1,35 @@
+package crownmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// published counts the runs of TestPublish, so that each publishes a new name:
+// expvar names cannot be reused.
+var published int
+
+func TestPublish(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-17T09:00:00Z")
+	clock := crown.NewClock(refT)
+	published++
+	name := fmt.Sprintf("%s_%d", t.Name(), published)
+	Publish(name, clock)
+	timer := clock.NewTimer(time.Hour)
+	defer timer.Stop()
+	clock.Forward(time.Minute)
+	clock.Forward(time.Minute)
+
+	var stats crown.Stats
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &stats); err != nil {
+		t.Fatalf("Should publish JSON, got %v", err)
+	}
+	if stats.Waiters != 1 || stats.Advances != 2 || stats.Advanced != 2*time.Minute {
+		t.Errorf("Should publish the stats of the clock, got %+v", stats)
+	}
+}
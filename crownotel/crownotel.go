@@ -0,0 +1,114 @@
+// Package crownotel records the activity of crown clocks on OpenTelemetry
+// spans, so that the traces captured during integration tests show the
+// simulated timeline alongside the real spans.
+//
+// It lives in its own module, so that crown itself does not depend on
+// OpenTelemetry.
+package crownotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/enzzc/crown"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of the span events and attributes recorded by the observer.
+const (
+	EventAdvance  = "crown.advance"
+	EventFire     = "crown.fire"
+	EventLongWait = "crown.long_wait"
+
+	AttrTime     = attribute.Key("crown.time")
+	AttrAdvance  = attribute.Key("crown.advance")
+	AttrDeadline = attribute.Key("crown.deadline")
+	AttrWaited   = attribute.Key("crown.waited")
+	AttrError    = attribute.Key("crown.error")
+	AttrWaitID   = attribute.Key("crown.wait.id")
+	AttrTrace    = attribute.Key("crown.wait.trace")
+)
+
+// TraceContext returns the trace and span IDs of the span of ctx, joined by a
+// dash, or the empty string if ctx has no valid span. It is meant to be passed
+// to crown.WithTraceContext, so that the events of a wait identify the span
+// which registered it.
+func TraceContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String() + "-" + sc.SpanID().String()
+}
+
+// Option configures the observer returned by Observer.
+type Option func(*observer)
+
+// WithLongWait sets the duration, in clock time, from which a wait is
+// reported with a crown.long_wait event once it ends, whether it fired or was
+// interrupted. It defaults to one hour. A non-positive d disables the events.
+func WithLongWait(d time.Duration) Option {
+	return func(o *observer) {
+		o.longWait = d
+	}
+}
+
+type observer struct {
+	span     trace.Span
+	longWait time.Duration
+}
+
+// Observer returns a clock observer, to be passed to crown.WithObserver,
+// which records the advances of the clock and the fires of its timers as
+// events of the span of ctx, and keeps the crown.time attribute of the span
+// set to the current time of the clock. Nothing is recorded if the span is
+// not recording.
+func Observer(ctx context.Context, opts ...Option) func(crown.Event) {
+	o := &observer{span: trace.SpanFromContext(ctx), longWait: time.Hour}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o.observe
+}
+
+func (o *observer) observe(e crown.Event) {
+	if !o.span.IsRecording() {
+		return
+	}
+	switch e.Kind {
+	case crown.EventAdvance:
+		o.span.SetAttributes(AttrTime.String(formatTime(e.Time)))
+		o.span.AddEvent(EventAdvance, trace.WithAttributes(
+			AttrTime.String(formatTime(e.Time)),
+			AttrAdvance.String(e.Advance.String()),
+		))
+	case crown.EventFire:
+		o.span.AddEvent(EventFire, trace.WithAttributes(o.waitAttributes(e)...))
+	}
+	if e.Kind == crown.EventFire || e.Kind == crown.EventCancel {
+		if o.longWait > 0 && e.Waited() >= o.longWait {
+			o.span.AddEvent(EventLongWait, trace.WithAttributes(o.waitAttributes(e)...))
+		}
+	}
+}
+
+func (o *observer) waitAttributes(e crown.Event) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		AttrTime.String(formatTime(e.Time)),
+		AttrDeadline.String(formatTime(e.Deadline)),
+		AttrWaited.String(e.Waited().String()),
+		AttrWaitID.Int64(e.ID),
+	}
+	if e.Trace != "" {
+		attrs = append(attrs, AttrTrace.String(e.Trace))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, AttrError.String(e.Err.Error()))
+	}
+	return attrs
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,93 @@
+package crownotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestObserver(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "scenario")
+
+	refT, _ := time.Parse(time.RFC3339, "2022-12-14T09:00:00Z")
+	clock := crown.NewClock(refT, crown.WithWakeAck(), crown.WithObserver(Observer(ctx, WithLongWait(2*time.Hour))))
+	short := clock.NewTimer(time.Minute)
+	long := clock.NewTimer(3 * time.Hour)
+	clock.Forward(time.Hour)
+	<-short.C
+	clock.Forward(2 * time.Hour)
+	<-long.C
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Should record 1 span, got %d", len(spans))
+	}
+	var names []string
+	for _, e := range spans[0].Events() {
+		names = append(names, e.Name)
+	}
+	want := []string{EventAdvance, EventFire, EventAdvance, EventFire, EventLongWait}
+	if len(names) != len(want) {
+		t.Fatalf("Should record events %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Should record events %v, got %v", want, names)
+			break
+		}
+	}
+	var got string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == AttrTime {
+			got = attr.Value.AsString()
+		}
+	}
+	if want := "2022-12-14T12:00:00Z"; got != want {
+		t.Errorf("Span should have crown.time %q, got %q", want, got)
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, span := provider.Tracer("test").Start(context.Background(), "scenario")
+	sleepCtx, sleepSpan := provider.Tracer("test").Start(ctx, "sleep")
+
+	refT, _ := time.Parse(time.RFC3339, "2022-12-14T09:00:00Z")
+	clock := crown.NewClock(refT, crown.WithTraceContext(TraceContext), crown.WithObserver(Observer(ctx)))
+	done := make(chan struct{})
+	go func() {
+		clock.SleepWithContext(sleepCtx, time.Minute)
+		close(done)
+	}()
+	for clock.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(time.Minute)
+	<-done
+	sleepSpan.End()
+	span.End()
+
+	want := TraceContext(sleepCtx)
+	for _, s := range recorder.Ended() {
+		for _, e := range s.Events() {
+			if e.Name != EventFire {
+				continue
+			}
+			for _, attr := range e.Attributes {
+				if attr.Key == AttrTrace && attr.Value.AsString() == want {
+					return
+				}
+			}
+			t.Fatalf("Fire event should carry the trace %q, got %v", want, e.Attributes)
+		}
+	}
+	t.Fatalf("No fire event recorded")
+}
@@ -0,0 +1,232 @@
+// Package crownsql wraps database/sql drivers to inject latency simulated on
+// a crown clock, so that the timeout and retry paths of a data access layer
+// can be tested without a slow database.
+//
+// Queries and statements wait for their latency on the clock before reaching
+// the wrapped driver. The deadlines of their contexts are interpreted as times
+// of the clock: a query whose latency outlasts the deadline fails with
+// context.DeadlineExceeded once the clock reaches the deadline, without
+// reaching the driver.
+package crownsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Option configures the latency injected by a wrapper.
+type Option func(*config)
+
+// WithLatency makes every query and statement execution wait for d.
+func WithLatency(d time.Duration) Option {
+	return WithLatencyFunc(func(string) time.Duration { return d })
+}
+
+// WithLatencyFunc makes every query and statement execution wait for the
+// duration returned by fn for its query. The function fn must be safe for
+// concurrent use.
+func WithLatencyFunc(fn func(query string) time.Duration) Option {
+	return func(cfg *config) {
+		cfg.latency = fn
+	}
+}
+
+type config struct {
+	clock   *crown.Clock
+	latency func(query string) time.Duration
+}
+
+// wait waits for the latency of query, or until the deadline of ctx if it
+// comes first.
+func (cfg *config) wait(ctx context.Context, query string) error {
+	var d time.Duration
+	if cfg.latency != nil {
+		d = cfg.latency(query)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := deadline.Sub(cfg.clock.Now()); left < d {
+			if err := cfg.clock.SleepWithContext(ctx, left); err != nil {
+				return err
+			}
+			return &crown.WaitError{Deadline: deadline, Err: context.DeadlineExceeded}
+		}
+	}
+	if d <= 0 {
+		return ctx.Err()
+	}
+	return cfg.clock.SleepWithContext(ctx, d)
+}
+
+func newConfig(c *crown.Clock, opts []Option) *config {
+	cfg := &config{clock: c}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Wrap returns a driver opening the connections of d, with the latency set by
+// opts simulated on the clock c. The returned driver is typically registered
+// with sql.Register.
+func Wrap(d driver.Driver, c *crown.Clock, opts ...Option) driver.Driver {
+	return &wrappedDriver{Driver: d, cfg: newConfig(c, opts)}
+}
+
+// WrapConnector is like Wrap for a driver.Connector, to be passed to
+// sql.OpenDB.
+func WrapConnector(ctr driver.Connector, c *crown.Clock, opts ...Option) driver.Connector {
+	return &connector{ctr: ctr, cfg: newConfig(c, opts)}
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	cfg *config
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	cn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, cfg: d.cfg}, nil
+}
+
+type connector struct {
+	ctr driver.Connector
+	cfg *config
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.ctr.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, cfg: c.cfg}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &wrappedDriver{Driver: c.ctr.Driver(), cfg: c.cfg}
+}
+
+type conn struct {
+	driver.Conn
+	cfg *config
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, query: query, cfg: c.cfg}, nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue returns driver.ErrSkip if the wrapped connection does not
+// check arguments itself, so that database/sql converts them by default.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if ch, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return ch.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// ExecContext returns driver.ErrSkip if the wrapped connection cannot execute
+// queries directly, so that database/sql prepares a statement instead.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.cfg.wait(ctx, query); err != nil {
+		return nil, err
+	}
+	return e.ExecContext(ctx, query, args)
+}
+
+// QueryContext is like ExecContext, for queries returning rows.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.cfg.wait(ctx, query); err != nil {
+		return nil, err
+	}
+	return q.QueryContext(ctx, query, args)
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+	cfg   *config
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.cfg.wait(ctx, s.query); err != nil {
+		return nil, err
+	}
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	return s.Stmt.Exec(values(args))
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := s.cfg.wait(ctx, s.query); err != nil {
+		return nil, err
+	}
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	return s.Stmt.Query(values(args))
+}
+
+// values converts ordinal arguments for the drivers predating named values.
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, arg := range args {
+		vals[i] = arg.Value
+	}
+	return vals
+}
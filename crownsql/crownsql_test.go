@@ -0,0 +1,172 @@
+package crownsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// fakeDriver records the statements it executes.
+type fakeDriver struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d: d}, nil }
+
+func (d *fakeDriver) executed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.queries...)
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	s.c.d.queries = append(s.c.d.queries, s.query)
+	s.c.d.mu.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.Exec(nil)
+	return fakeRows{}, nil
+}
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string         { return nil }
+func (fakeRows) Close() error              { return nil }
+func (fakeRows) Next([]driver.Value) error { return io.EOF }
+
+// clockDeadline is a context whose deadline is a time of the clock.
+type clockDeadline struct {
+	context.Context
+	deadline time.Time
+}
+
+func (ctx clockDeadline) Deadline() (time.Time, bool) { return ctx.deadline, true }
+
+func TestLatency(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-21T09:00:00Z")
+	clock := crown.NewClock(refT)
+	fake := &fakeDriver{}
+	db := sql.OpenDB(WrapConnector(fakeConnector{fake}, clock, WithLatencyFunc(func(query string) time.Duration {
+		if query == "SLOW" {
+			return time.Minute
+		}
+		return time.Second
+	})))
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := db.Exec("FAST")
+		done <- err
+	}()
+	for clock.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if got := fake.executed(); len(got) != 0 {
+		t.Errorf("Should not reach the driver before the latency, got %v", got)
+	}
+	clock.Forward(time.Second)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := clockDeadline{context.Background(), refT.Add(11 * time.Second)}
+	go func() {
+		_, err := db.QueryContext(ctx, "SLOW")
+		done <- err
+	}()
+	for clock.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(10 * time.Second)
+	if err := <-done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Should fail with DeadlineExceeded, got %v", err)
+	}
+	if got := fake.executed(); len(got) != 1 || got[0] != "FAST" {
+		t.Errorf("Should only execute FAST, got %v", got)
+	}
+}
+
+type fakeConnector struct{ d *fakeDriver }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.d }
+
+// checkedConn checks the arguments and tracks the validity of its session.
+type checkedConn struct {
+	fakeConn
+	reset bool
+	bad   bool
+}
+
+func (c *checkedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if d, ok := nv.Value.(time.Duration); ok {
+		nv.Value = d.String()
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+func (c *checkedConn) ResetSession(context.Context) error {
+	c.reset = true
+	return nil
+}
+
+func (c *checkedConn) IsValid() bool { return !c.bad }
+
+func TestConnInterfaces(t *testing.T) {
+	inner := &checkedConn{fakeConn: fakeConn{d: &fakeDriver{}}}
+	cn := &conn{Conn: inner, cfg: newConfig(crown.NewClock(time.Now()), nil)}
+
+	nv := driver.NamedValue{Ordinal: 1, Value: time.Second}
+	if err := cn.CheckNamedValue(&nv); err != nil || nv.Value != "1s" {
+		t.Errorf("Should check arguments with the wrapped connection, got %v, %v", nv.Value, err)
+	}
+	if err := cn.ResetSession(context.Background()); err != nil || !inner.reset {
+		t.Errorf("Should reset the session of the wrapped connection, got %v", err)
+	}
+	if inner.bad = true; cn.IsValid() {
+		t.Error("Should report the wrapped connection as invalid")
+	}
+
+	plain := &conn{Conn: &fakeConn{d: &fakeDriver{}}, cfg: cn.cfg}
+	if err := plain.CheckNamedValue(&nv); err != driver.ErrSkip {
+		t.Errorf("Should skip the check without a checker, got %v", err)
+	}
+	if err := plain.ResetSession(context.Background()); err != nil {
+		t.Errorf("Should reset without a resetter, got %v", err)
+	}
+	if !plain.IsValid() {
+		t.Error("Should be valid without a validator")
+	}
+}
@@ -0,0 +1,101 @@
+//go:build go1.25
+
+package crownsynctest
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Test runs f in a synctest bubble, like synctest.Test, with a crown clock
+// created with opts and starting at the time of the bubble. The clock follows
+// the fake time of the bubble: when every goroutine of the bubble is durably
+// blocked, including those waiting on the clock, the time of the bubble jumps
+// to the next deadline, be it a deadline of the time package or of the clock,
+// and the clock is advanced to the new time of the bubble once one of its
+// deadlines is reached.
+//
+// The clock is only moved when one of its deadlines is reached, so it lags
+// behind the bubble meanwhile: the waits registered then are counted from its
+// own time. The clock is closed once f returns, releasing the waits still
+// pending so that the bubble can exit.
+func Test(t *testing.T, f func(t *testing.T, c *crown.Clock), opts ...crown.Option) {
+	t.Helper()
+	synctest.Test(t, func(t *testing.T) {
+		d := &driver{
+			wake: make(chan struct{}, 1),
+			stop: make(chan struct{}),
+			done: make(chan struct{}),
+		}
+		opts := append(opts[:len(opts):len(opts)], crown.WithObserver(d.observe))
+		d.clock = crown.NewClock(time.Now(), opts...)
+		go d.run()
+		defer func() {
+			close(d.stop)
+			<-d.done
+			d.clock.Close()
+		}()
+		f(t, d.clock)
+	})
+}
+
+// driver advances the clock along with the bubble.
+type driver struct {
+	// waiters is the number of waits registered and not released, according
+	// to the events. It comes first to be 64-bit aligned.
+	waiters int64
+	clock   *crown.Clock
+	wake    chan struct{} // signaled when a wait is registered
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (d *driver) observe(e crown.Event) {
+	switch e.Kind {
+	case crown.EventFire, crown.EventCancel:
+		atomic.AddInt64(&d.waiters, -1)
+		return
+	case crown.EventRegister:
+		atomic.AddInt64(&d.waiters, 1)
+	default:
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *driver) run() {
+	defer close(d.done)
+	for {
+		// A wait is reported registered right before it is visible, and
+		// released right after it is not.
+		pending := d.clock.Pending()
+		for int64(len(pending)) != atomic.LoadInt64(&d.waiters) {
+			runtime.Gosched()
+			pending = d.clock.Pending()
+		}
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if len(pending) > 0 {
+			timer = time.NewTimer(pending[0].Deadline.Sub(d.clock.Now()))
+			fire = timer.C
+		}
+		select {
+		case <-fire:
+			d.clock.Forward(time.Since(d.clock.Now()))
+		case <-d.wake:
+		case <-d.stop:
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
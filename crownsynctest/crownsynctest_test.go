@@ -0,0 +1,40 @@
+//go:build go1.25
+
+package crownsynctest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestMixedWaits(t *testing.T) {
+	Test(t, func(t *testing.T, c *crown.Clock) {
+		start := time.Now()
+		timer := c.NewTimer(time.Hour)
+		done := make(chan time.Duration)
+		go func() {
+			time.Sleep(10 * time.Minute)
+			done <- time.Since(start)
+		}()
+
+		if got := <-done; got != 10*time.Minute {
+			t.Errorf("time.Sleep should last 10m, got %v", got)
+		}
+		fired := <-timer.C
+		if want := start.Add(time.Hour); !fired.Equal(want) {
+			t.Errorf("Timer should fire at %q, got %q", want, fired)
+		}
+		if got := time.Since(start); got != time.Hour {
+			t.Errorf("Bubble time should follow the clock, got %v", got)
+		}
+
+		c.Sleep(time.Minute)
+		if got := c.Now().Sub(start); got != time.Hour+time.Minute {
+			t.Errorf("Clock should have moved by 1h1m, got %v", got)
+		}
+		// Pending waits are released when the clock is closed.
+		c.NewTimer(time.Hour)
+	})
+}
@@ -0,0 +1,4 @@
+// Package crownsynctest runs crown clocks inside testing/synctest bubbles, so
+// that code timed by crown and code timed by the time package can be tested
+// together. It requires Go 1.25 or later.
+package crownsynctest
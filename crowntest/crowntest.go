@@ -0,0 +1,103 @@
+// Package crowntest integrates crown clocks with the testing package: clocks
+// created by NewClock fail their test if waits are left pending when it ends,
+// and the helpers of the package report their failures with the state of the
+// clock.
+package crowntest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// WaitTimeout is how long WaitForWaiters waits, in real time, before failing
+// the test.
+var WaitTimeout = 10 * time.Second
+
+// NewClock returns a clock starting at start, created with opts and with
+// stack capture, see crown.WithStackCapture. Once the test and its subtests
+// have completed, the test fails if waits are still pending on the clock, see
+// VerifyNoPending, then the clock is closed so that the goroutines blocked on
+// it return.
+func NewClock(t testing.TB, start time.Time, opts ...crown.Option) *crown.Clock {
+	t.Helper()
+	opts = append(opts[:len(opts):len(opts)], crown.WithStackCapture())
+	c := crown.NewClock(start, opts...)
+	t.Cleanup(func() {
+		defer c.Close()
+		VerifyNoPending(t, c)
+	})
+	return c
+}
+
+// VerifyNoPending fails the test if waits are pending on c, listing for each
+// its deadline, its age and, if c was created with crown.WithStackCapture,
+// the goroutine and the stack which registered it.
+func VerifyNoPending(t testing.TB, c *crown.Clock) {
+	t.Helper()
+	pending := c.Pending()
+	if len(pending) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "crowntest: %d wait(s) still pending at %s", len(pending), c.Now().Format(time.RFC3339Nano))
+	for _, w := range pending {
+		fmt.Fprintf(&b, "\n\n%s %d until %s, registered %s ago", w.Kind, w.ID, w.Deadline.Format(time.RFC3339Nano), w.Age)
+		if w.Stack != "" {
+			fmt.Fprintf(&b, " by goroutine %d at:\n%s", w.Goroutine, w.Stack)
+		}
+	}
+	b.WriteString("\n\nStop the timers and tickers, and cancel the waits, before the test ends.")
+	t.Error(b.String())
+}
+
+// Strict returns an option making the clock fail the test on every wait
+// registered while none is expected, see crown.WithStrict and
+// crown.Clock.Expect.
+func Strict(t testing.TB) crown.Option {
+	return crown.WithStrict(func(err error) {
+		t.Error(err)
+	})
+}
+
+// AdvanceAndWait moves c by d, and returns once the sleepers released by the
+// move have resumed, see crown.Clock.ForwardAndWait. It fails the test if d
+// is negative.
+func AdvanceAndWait(t testing.TB, c *crown.Clock, d time.Duration) {
+	t.Helper()
+	if d < 0 {
+		t.Fatalf("crowntest: cannot advance the clock by %s", d)
+	}
+	c.ForwardAndWait(d)
+}
+
+// WaitForWaiters blocks until exactly n sleepers, timers and tickers are
+// waiting on c, see crown.Clock.BlockUntil. It fails the test, describing the
+// waits pending on c, if they are not n after WaitTimeout.
+func WaitForWaiters(t testing.TB, c *crown.Clock, n int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTimeout)
+	defer cancel()
+	if c.BlockUntilContext(ctx, n) != nil {
+		t.Fatalf("crowntest: %d wait(s) pending after %s, want %d%s", c.Waiters(), WaitTimeout, n, Describe(c))
+	}
+}
+
+// Describe returns a description of the waits pending on c, suitable for
+// failure messages, or the empty string if there is none.
+func Describe(c *crown.Clock) string {
+	pending := c.Pending()
+	if len(pending) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nclock at %s, waits pending:", c.Now().Format(time.RFC3339Nano))
+	for _, w := range pending {
+		fmt.Fprintf(&b, "\n\t%s %d until %s (in %s)", w.Kind, w.ID, w.Deadline.Format(time.RFC3339Nano), w.Deadline.Sub(c.Now()))
+	}
+	return b.String()
+}
@@ -0,0 +1,131 @@
+package crowntest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder records the failures and cleanups of a test.
+type recorder struct {
+	testing.TB
+	failures []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Error(args ...any) {
+	r.failures = append(r.failures, fmt.Sprint(args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// run runs f as a test, and returns its recorder once its cleanups ran.
+func run(f func(t testing.TB)) *recorder {
+	r := &recorder{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(r)
+	}()
+	<-done
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+	return r
+}
+
+func TestNewClock(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-08T09:00:00Z")
+	slept := make(chan error, 1)
+	r := run(func(t testing.TB) {
+		c := NewClock(t, refT)
+		timer := c.NewTimer(time.Minute)
+		timer.Stop()
+		c.NewTimer(time.Hour)
+		go func() {
+			slept <- c.SleepWithContext(context.Background(), time.Hour)
+		}()
+		WaitForWaiters(t, c, 2)
+		c.Forward(time.Minute)
+	})
+	if len(r.failures) != 1 {
+		t.Fatalf("Should report the pending waits once, got %q", r.failures)
+	}
+	if msg := r.failures[0]; !strings.Contains(msg, "2 wait(s) still pending") ||
+		!strings.Contains(msg, "registered 1m0s ago by goroutine") || !strings.Contains(msg, "crowntest.TestNewClock") {
+		t.Errorf("Should describe the pending waits, got:\n%s", msg)
+	}
+	select {
+	case err := <-slept:
+		if err == nil {
+			t.Errorf("Sleep should be interrupted by the end of the test")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Sleep was not released at the end of the test")
+	}
+
+	r = run(func(t testing.TB) {
+		c := NewClock(t, refT)
+		timer := c.NewTimer(time.Minute)
+		AdvanceAndWait(t, c, time.Minute)
+		<-timer.C
+	})
+	if len(r.failures) != 0 {
+		t.Errorf("Should not fail, got %q", r.failures)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-08T09:00:00Z")
+	r := run(func(t testing.TB) {
+		c := NewClock(t, refT, Strict(t))
+		c.Expect(1)
+		c.Sleep(0)
+		c.Sleep(0)
+	})
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "unexpected wait: sleep") {
+		t.Errorf("Should report the second sleep, got %q", r.failures)
+	}
+}
+
+func TestWaitForWaiters(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-08T09:00:00Z")
+	defer func(d time.Duration) { WaitTimeout = d }(WaitTimeout)
+	WaitTimeout = 10 * time.Millisecond
+	r := run(func(t testing.TB) {
+		c := NewClock(t, refT)
+		timer := c.NewTimer(time.Minute)
+		defer timer.Stop()
+		WaitForWaiters(t, c, 2)
+		t.Errorf("WaitForWaiters should stop the test")
+	})
+	if len(r.failures) != 1 {
+		t.Fatalf("Should fail once, got %q", r.failures)
+	}
+	if msg := r.failures[0]; !strings.Contains(msg, "1 wait(s) pending after 10ms, want 2") || !strings.Contains(msg, "timer 1 until 2023-01-08T09:01:00Z (in 1m0s)") {
+		t.Errorf("Should describe the pending waits, got:\n%s", msg)
+	}
+
+	r = run(func(t testing.TB) {
+		AdvanceAndWait(t, NewClock(t, refT), -time.Second)
+	})
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "cannot advance the clock by -1s") {
+		t.Errorf("Should refuse a negative advance, got %q", r.failures)
+	}
+}
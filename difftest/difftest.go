@@ -0,0 +1,246 @@
+// Package difftest runs scenarios of timer operations against both a crown
+// Clock and the real time package, and reports where their observable
+// behaviors differ. It is meant to continuously validate that crown timers
+// and tickers behave like their time counterparts.
+//
+// On the real side, simulated durations are scaled down: every Quantum of
+// simulated time lasts Scale of real time. All the durations of a scenario
+// must be multiples of Quantum, so that real timers never fire close to the
+// instants where the harness observes them.
+package difftest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Kind is the kind of an operation.
+type Kind int
+
+const (
+	// NewTimer creates the timer Name, firing after D.
+	NewTimer Kind = iota
+	// StopTimer stops the timer Name.
+	StopTimer
+	// ResetTimer resets the timer Name to fire after D.
+	ResetTimer
+	// NewTicker creates the ticker Name, of period D.
+	NewTicker
+	// StopTicker stops the ticker Name.
+	StopTicker
+	// Advance lets D elapse, then observes the channels which delivered.
+	Advance
+)
+
+// Op is an operation of a scenario.
+type Op struct {
+	Kind Kind
+	Name string
+	D    time.Duration
+}
+
+// Scenario is a sequence of operations. Timers and tickers are referred to by
+// their name, which must be unique.
+type Scenario []Op
+
+// Options configures the runs of the real side of a scenario.
+type Options struct {
+	// Quantum is the simulated duration all the durations of the scenario
+	// are multiple of. It defaults to one second.
+	Quantum time.Duration
+	// Scale is the real duration lasted by one Quantum. It defaults to 20ms.
+	Scale time.Duration
+	// Clock is passed to RunCrown, to configure the crown clock.
+	Clock []crown.Option
+}
+
+// Step holds what was observed between two advances: the results of the
+// operations, then the names of the timers and tickers which delivered
+// during the advance, once per value received.
+type Step struct {
+	Results   []string
+	Delivered []string
+}
+
+// Compare runs s against both a crown clock and the real time package, and
+// returns an error describing the first step where they differ.
+func Compare(s Scenario, opts Options) error {
+	fake := RunCrown(s, opts.Clock...)
+	real := RunReal(s, opts)
+	for i := range fake {
+		if !reflect.DeepEqual(fake[i], real[i]) {
+			return fmt.Errorf("difftest: step %d differs: crown observed %+v, time observed %+v", i, fake[i], real[i])
+		}
+	}
+	return nil
+}
+
+// RunCrown runs s against a crown clock created with opts, and returns its
+// observations. The clock is always created with crown.WithWakeAck, so that
+// the deliveries of an advance are observed right after it.
+func RunCrown(s Scenario, opts ...crown.Option) []Step {
+	clock := crown.NewClock(time.Time{}, append([]crown.Option{crown.WithWakeAck()}, opts...)...)
+	defer clock.Close()
+	timers := map[string]*crown.Timer{}
+	tickers := map[string]*crown.Ticker{}
+
+	// active returns the number of waits expected on the clock.
+	active := func() int {
+		n := len(tickers)
+		for _, t := range timers {
+			if t.State() == crown.TimerPending {
+				n++
+			}
+		}
+		return n
+	}
+
+	steps := []Step{{}}
+	for _, op := range s {
+		step := &steps[len(steps)-1]
+		switch op.Kind {
+		case NewTimer:
+			timers[op.Name] = clock.NewTimer(op.D)
+		case StopTimer:
+			step.Results = append(step.Results, result(op, timers[op.Name].Stop()))
+		case ResetTimer:
+			step.Results = append(step.Results, result(op, timers[op.Name].Reset(op.D)))
+		case NewTicker:
+			tickers[op.Name] = clock.NewTickerWithPolicy(op.D, crown.Skip)
+		case StopTicker:
+			tickers[op.Name].Stop()
+			delete(tickers, op.Name)
+		case Advance:
+			for clock.Waiters() != active() {
+				time.Sleep(100 * time.Microsecond)
+			}
+			clock.Forward(op.D)
+			for name, t := range timers {
+				step.Delivered = append(step.Delivered, drain(name, t.C)...)
+			}
+			for name, t := range tickers {
+				step.Delivered = append(step.Delivered, drain(name, t.C)...)
+			}
+			sort.Strings(step.Delivered)
+			steps = append(steps, Step{})
+		}
+	}
+	return steps
+}
+
+// RunReal runs s against the real time package, and returns its
+// observations.
+//
+// Timers are created so that they fire on the simulated timeline, and are
+// observed a quarter of a Quantum after each advance, before the following
+// operations. Tickers cannot be aligned in the same way, so they are observed
+// later, three quarters of a Quantum after each advance, and are only stopped
+// then.
+func RunReal(s Scenario, opts Options) []Step {
+	if opts.Quantum <= 0 {
+		opts.Quantum = time.Second
+	}
+	if opts.Scale <= 0 {
+		opts.Scale = 20 * time.Millisecond
+	}
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) / float64(opts.Quantum) * float64(opts.Scale))
+	}
+	timers := map[string]*time.Timer{}
+	tickers := map[string]*time.Ticker{}
+	var stops []string // tickers to stop once observed
+
+	start := time.Now()
+	var elapsed time.Duration // simulated
+	at := func(d time.Duration) time.Time {
+		return start.Add(scale(elapsed + d))
+	}
+	// observeTickers observes the tickers for the step which has ended at
+	// the current simulated time, if any, then performs the stops of the
+	// following step, which would otherwise discard the pending ticks.
+	observeTickers := func(step *Step) {
+		time.Sleep(time.Until(at(0).Add(opts.Scale * 3 / 4)))
+		if step != nil {
+			for name, t := range tickers {
+				step.Delivered = append(step.Delivered, drain(name, t.C)...)
+			}
+			sort.Strings(step.Delivered)
+		}
+		for _, name := range stops {
+			tickers[name].Stop()
+			delete(tickers, name)
+		}
+		stops = nil
+	}
+	previous := func(steps []Step) *Step {
+		if len(steps) < 2 {
+			return nil
+		}
+		return &steps[len(steps)-2]
+	}
+
+	steps := []Step{{}}
+	for _, op := range s {
+		step := &steps[len(steps)-1]
+		switch op.Kind {
+		case NewTimer:
+			timers[op.Name] = time.NewTimer(time.Until(at(op.D)))
+		case StopTimer:
+			step.Results = append(step.Results, result(op, timers[op.Name].Stop()))
+		case ResetTimer:
+			step.Results = append(step.Results, result(op, timers[op.Name].Reset(time.Until(at(op.D)))))
+		case NewTicker:
+			tickers[op.Name] = time.NewTicker(scale(op.D))
+		case StopTicker:
+			stops = append(stops, op.Name)
+		case Advance:
+			observeTickers(previous(steps))
+			elapsed += op.D
+			time.Sleep(time.Until(at(0).Add(opts.Scale / 4)))
+			for name, t := range timers {
+				step.Delivered = append(step.Delivered, drain(name, t.C)...)
+			}
+			steps = append(steps, Step{})
+		}
+	}
+	observeTickers(previous(steps))
+	for _, t := range timers {
+		t.Stop()
+	}
+	for _, t := range tickers {
+		t.Stop()
+	}
+	return steps
+}
+
+func result(op Op, v bool) string {
+	name := map[Kind]string{StopTimer: "stop", ResetTimer: "reset"}[op.Kind]
+	return fmt.Sprintf("%s %s: %v", name, op.Name, v)
+}
+
+// drain returns name once per value immediately available on c, until c is
+// closed.
+func drain(name string, c <-chan time.Time) []string {
+	var names []string
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return names
+			}
+			names = append(names, name)
+		default:
+			return names
+		}
+	}
+}
+
+// String returns a compact representation of the step.
+func (s Step) String() string {
+	return "results=[" + strings.Join(s.Results, ", ") + "] delivered=[" + strings.Join(s.Delivered, ", ") + "]"
+}
@@ -0,0 +1,51 @@
+package difftest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestCompare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs in real time")
+	}
+	s := Scenario{
+		{Kind: NewTimer, Name: "a", D: 2 * time.Second},
+		{Kind: NewTimer, Name: "b", D: 3 * time.Second},
+		{Kind: NewTicker, Name: "t", D: 2 * time.Second},
+		{Kind: Advance, D: time.Second},
+		{Kind: StopTimer, Name: "b"},
+		{Kind: Advance, D: time.Second},
+		{Kind: ResetTimer, Name: "a", D: 2 * time.Second},
+		{Kind: StopTimer, Name: "b"},
+		{Kind: Advance, D: 3 * time.Second},
+		{Kind: StopTicker, Name: "t"},
+		{Kind: Advance, D: 2 * time.Second},
+	}
+	modes := map[string][]crown.Option{
+		"close":       nil,
+		"stdChannels": {crown.WithStdChannels()},
+	}
+	want := []Step{
+		{},
+		{Results: []string{"stop b: true"}, Delivered: []string{"a", "t"}},
+		{Results: []string{"reset a: false", "stop b: false"}, Delivered: []string{"a", "t"}},
+		{},
+		{},
+	}
+	for mode, opts := range modes {
+		t.Run(mode, func(t *testing.T) {
+			if err := Compare(s, Options{Scale: 50 * time.Millisecond, Clock: opts}); err != nil {
+				t.Error(err)
+			}
+			got := RunCrown(s, opts...)
+			for i := range want {
+				if got[i].String() != want[i].String() {
+					t.Errorf("Step %d should be %v, got %v instead", i, want[i], got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package crown
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DumpString returns a human-readable table of the waits pending on the
+// clock, sorted by deadline, to be printed in test logs or panic messages:
+//
+//	crown: 2 wait(s) pending at 2022-12-20T09:00:00Z
+//	ID  KIND   DEADLINE              REMAINING  LABEL
+//	3   timer  2022-12-20T09:01:00Z  1m0s       pkg.TestRetry
+//	1   sleep  2022-12-20T10:00:00Z  1h0m0s     -
+//
+// The label of a wait is its trace identifier (see WithTraceContext) or else
+// the function which registered it, provided the clock was created with
+// WithStackCapture.
+func (c *Clock) DumpString() string {
+	now := c.Now()
+	pending := c.pendingHandlers()
+	var b strings.Builder
+	fmt.Fprintf(&b, "crown: %d wait(s) pending at %s\n", len(pending), now.Format(time.RFC3339Nano))
+	if len(pending) == 0 {
+		return b.String()
+	}
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tDEADLINE\tREMAINING\tLABEL")
+	for _, handler := range pending {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", handler.id, handler.kind,
+			handler.deadline.Format(time.RFC3339Nano), handler.deadline.Sub(now), handler.label())
+	}
+	w.Flush()
+	return b.String()
+}
+
+// label returns the trace identifier of the wait, or else the first function
+// outside of the package which registered it, or "-" if it is unknown.
+func (h *sleepHandler) label() string {
+	if h.trace != "" {
+		return h.trace
+	}
+	frames := runtime.CallersFrames(h.stack)
+	for more := len(h.stack) > 0; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		if !strings.HasPrefix(frame.Function, pkgPath+".(*") {
+			return frame.Function
+		}
+	}
+	return "-"
+}
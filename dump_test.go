@@ -0,0 +1,27 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDumpString(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-20T09:00:00Z")
+	clock := NewClock(refT, WithStackCapture())
+	if got, want := clock.DumpString(), "crown: 0 wait(s) pending at 2022-12-20T09:00:00Z\n"; got != want {
+		t.Errorf("Should dump %q, got %q", want, got)
+	}
+	ticker := clock.NewTicker(time.Hour)
+	defer ticker.Stop()
+	timer := clock.NewTimer(time.Minute)
+	defer timer.Stop()
+
+	want := `crown: 2 wait(s) pending at 2022-12-20T09:00:00Z
+ID  KIND    DEADLINE              REMAINING  LABEL
+2   timer   2022-12-20T09:01:00Z  1m0s       github.com/enzzc/crown.TestDumpString
+1   ticker  2022-12-20T10:00:00Z  1h0m0s     github.com/enzzc/crown.TestDumpString
+`
+	if got := clock.DumpString(); got != want {
+		t.Errorf("Should dump:\n%s\ngot:\n%s", want, got)
+	}
+}
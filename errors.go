@@ -0,0 +1,48 @@
+package crown
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrClockClosed is the cause of the waits interrupted by, or started
+	// after, the closure of their clock.
+	ErrClockClosed = errors.New("crown: clock closed")
+
+	// ErrCanceled is the cause of the waits interrupted by Clock.CancelAll.
+	ErrCanceled = errors.New("crown: wait canceled")
+
+	// ErrNonMonotonic is the panic value used when an operation would move
+	// the time of a clock created with WithStrictMonotonic backward.
+	ErrNonMonotonic = errors.New("crown: time cannot move backward on a monotonic clock")
+
+	// ErrUnexpectedWait is reported when a wait is registered on a clock
+	// created with WithStrict while none is expected.
+	ErrUnexpectedWait = errors.New("crown: unexpected wait")
+)
+
+// WaitError is the error returned when a wait on a clock is interrupted before
+// its deadline. Err, the cause of the interruption, can be context.Canceled,
+// context.DeadlineExceeded, ErrClockClosed or ErrCanceled, and is reported by
+// errors.Is.
+type WaitError struct {
+	Deadline time.Time // when the wait was due to end
+	Err      error
+}
+
+func (e *WaitError) Error() string {
+	return "crown: wait until " + e.Deadline.Format(time.RFC3339Nano) + " interrupted: " + e.Err.Error()
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// waitError returns err wrapped in a *WaitError, or nil if err is nil.
+func waitError(deadline time.Time, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &WaitError{Deadline: deadline, Err: err}
+}
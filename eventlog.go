@@ -0,0 +1,128 @@
+package crown
+
+import "sync"
+
+// EventLog records the latest events of a clock in a ring buffer, or every
+// event, so that tests and tooling can examine what the clock did during a
+// scenario, and export its timeline with the timeline package. It is
+// attached to a clock with WithEventLog. An EventLog is safe for concurrent
+// use.
+type EventLog struct {
+	mu     sync.Mutex
+	events []Event // ring buffer, oldest at next once full
+	next   int
+	full   bool
+	// unbounded is set if the log keeps every event.
+	unbounded bool
+	subs      map[*subscription]struct{}
+}
+
+type subscription struct {
+	ch      chan Event
+	dropped int
+}
+
+// NewEventLog returns an EventLog keeping the last size events, or every
+// event if size is zero, to record the whole timeline of a run. It panics if
+// size is negative.
+func NewEventLog(size int) *EventLog {
+	if size < 0 {
+		panic("crown: negative size for NewEventLog")
+	}
+	return &EventLog{events: make([]Event, size), unbounded: size == 0}
+}
+
+// WithEventLog makes the clock record its events in l.
+func WithEventLog(l *EventLog) Option {
+	return WithObserver(l.Observe)
+}
+
+// Observe records e, and sends it to the subscribers. It can be passed to
+// WithObserver.
+func (l *EventLog) Observe(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.unbounded {
+		l.events = append(l.events, e)
+		l.next++
+	} else {
+		l.events[l.next] = e
+		l.next++
+		if l.next == len(l.events) {
+			l.next = 0
+			l.full = true
+		}
+	}
+	for sub := range l.subs {
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Events returns the recorded events, oldest first.
+func (l *EventLog) Events() []Event {
+	return l.Query(nil)
+}
+
+// Query returns the recorded events for which match returns true, oldest
+// first. A nil match selects every event.
+func (l *EventLog) Query(match func(Event) bool) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var events []Event
+	if l.full {
+		events = append(events, l.events[l.next:]...)
+	}
+	events = append(events, l.events[:l.next]...)
+	if match == nil {
+		return events
+	}
+	n := 0
+	for _, e := range events {
+		if match(e) {
+			events[n] = e
+			n++
+		}
+	}
+	return events[:n]
+}
+
+// Kinds returns a Query matcher selecting the events of the given kinds.
+func Kinds(kinds ...EventKind) func(Event) bool {
+	return func(e Event) bool {
+		for _, k := range kinds {
+			if e.Kind == k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Subscribe returns a channel receiving the events recorded from now on, with
+// a buffer of the given size, and a function ending the subscription and
+// returning the number of events dropped because the buffer was full: the
+// clock is never blocked by a slow subscriber. The channel is closed once the
+// subscription ends.
+func (l *EventLog) Subscribe(buffer int) (<-chan Event, func() int) {
+	sub := &subscription{ch: make(chan Event, buffer)}
+	l.mu.Lock()
+	if l.subs == nil {
+		l.subs = make(map[*subscription]struct{})
+	}
+	l.subs[sub] = struct{}{}
+	l.mu.Unlock()
+	var once sync.Once
+	return sub.ch, func() int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		once.Do(func() {
+			delete(l.subs, sub)
+			close(sub.ch)
+		})
+		return sub.dropped
+	}
+}
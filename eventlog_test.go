@@ -0,0 +1,60 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventLog(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-14T09:00:00Z")
+	log := NewEventLog(4)
+	clock := NewClock(refT, WithWakeAck(), WithEventLog(log))
+	events, unsubscribe := log.Subscribe(2)
+
+	timer := clock.NewTimer(time.Second)
+	clock.Forward(time.Second)
+	<-timer.C
+	clock.Forward(time.Second)
+	clock.Forward(time.Second)
+
+	// register, advance, fire, advance, advance: the first one is overwritten.
+	got := log.Events()
+	want := []EventKind{EventAdvance, EventFire, EventAdvance, EventAdvance}
+	if len(got) != len(want) {
+		t.Fatalf("Should keep %d events, got %v", len(want), got)
+	}
+	for i, e := range got {
+		if e.Kind != want[i] {
+			t.Errorf("Event %d should be %v, got %v instead", i, want[i], e.Kind)
+		}
+	}
+	if got := log.Query(Kinds(EventFire)); len(got) != 1 || !got[0].Time.Equal(refT.Add(time.Second)) {
+		t.Errorf("Should find the fire at %q, got %v", refT.Add(time.Second), got)
+	}
+
+	if e := <-events; e.Kind != EventRegister {
+		t.Errorf("Subscriber should receive the registration first, got %v", e.Kind)
+	}
+	if e := <-events; e.Kind != EventAdvance {
+		t.Errorf("Subscriber should receive the advance next, got %v", e.Kind)
+	}
+	if dropped := unsubscribe(); dropped != 3 {
+		t.Errorf("Should drop 3 events, got %d", dropped)
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Channel should be closed after unsubscribing")
+	}
+}
+
+func TestEventLogUnbounded(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-14T09:00:00Z")
+	log := NewEventLog(0)
+	clock := NewClock(refT, WithEventLog(log))
+	for i := 0; i < 100; i++ {
+		clock.Forward(time.Second)
+	}
+	got := log.Events()
+	if len(got) != 100 || !got[0].Time.Equal(refT.Add(time.Second)) {
+		t.Errorf("Should keep every event, got %d from %v", len(got), got[0].Time)
+	}
+}
@@ -0,0 +1,112 @@
+package crown
+
+import (
+	"sync"
+	"time"
+)
+
+// ClockGroup moves several clocks together, such as the clocks of the nodes of
+// a simulated distributed system: each clock of the group is off the reference
+// time of the group by a constant offset, and drifts from it at a constant
+// rate, so that tests can verify the behavior of the system under clock skew.
+// A ClockGroup is created with NewClockGroup, and is safe for concurrent use.
+type ClockGroup struct {
+	moveMu  sync.Mutex // serializes the moves of the group
+	mu      sync.Mutex
+	now     time.Time // reference time
+	members []*member
+}
+
+// member is a clock of a group.
+type member struct {
+	clock  *Clock
+	offset time.Duration
+	drift  float64
+	since  time.Time // reference time when the clock joined the group
+}
+
+// at returns the time of the clock of m at the reference time now.
+func (m *member) at(now time.Time) time.Time {
+	elapsed := now.Sub(m.since)
+	return now.Add(m.offset + time.Duration(float64(elapsed)*m.drift))
+}
+
+// NewClockGroup returns a new group whose reference time starts at t.
+func NewClockGroup(t time.Time) *ClockGroup {
+	return &ClockGroup{now: t}
+}
+
+// Add returns a new clock of the group, created with opts, off the reference
+// time by offset, and drifting from it by drift seconds per second: a drift of
+// 1e-4 makes the clock gain 100µs every second of the reference time, while a
+// drift of -1e-4 makes it lose as much. The clock is expected to be moved by
+// the group only. The drift must be greater than -1, so that the time of the
+// clock moves forward; if not, Add panics.
+func (g *ClockGroup) Add(offset time.Duration, drift float64, opts ...Option) *Clock {
+	if drift <= -1 {
+		panic("crown: drift of -1 or less for ClockGroup.Add")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	m := &member{offset: offset, drift: drift, since: g.now}
+	m.clock = NewClock(m.at(g.now), opts...)
+	g.members = append(g.members, m)
+	return m.clock
+}
+
+// Clocks returns the clocks of the group, in the order they were added.
+func (g *ClockGroup) Clocks() []*Clock {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	clocks := make([]*Clock, len(g.members))
+	for i, m := range g.members {
+		clocks[i] = m.clock
+	}
+	return clocks
+}
+
+// Now returns the reference time of the group.
+func (g *ClockGroup) Now() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.now
+}
+
+// Forward moves the reference time of the group by d, and each clock of the
+// group accordingly, releasing their waits like Clock.Set.
+func (g *ClockGroup) Forward(d time.Duration) {
+	g.move(func(now time.Time) time.Time {
+		return now.Add(d)
+	})
+}
+
+// Set sets the reference time of the group to t, and moves each clock of the
+// group accordingly, releasing their waits like Clock.Set.
+func (g *ClockGroup) Set(t time.Time) {
+	g.move(func(time.Time) time.Time {
+		return t
+	})
+}
+
+// move sets the reference time to the time returned by target for the current
+// one, and moves the clocks. The clocks are moved without holding mu, so that
+// the goroutines they release can use the group.
+func (g *ClockGroup) move(target func(now time.Time) time.Time) {
+	g.moveMu.Lock()
+	defer g.moveMu.Unlock()
+	g.mu.Lock()
+	g.now = target(g.now)
+	now, members := g.now, g.members
+	g.mu.Unlock()
+	for _, m := range members {
+		m.clock.Set(m.at(now))
+	}
+}
+
+// Close closes the clocks of the group, see Clock.Close.
+func (g *ClockGroup) Close() error {
+	for _, c := range g.Clocks() {
+		c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockGroup(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-15T09:00:00Z")
+	g := NewClockGroup(refT)
+	defer g.Close()
+	exact := g.Add(0, 0)
+	ahead := g.Add(2*time.Second, 0)
+	fast := g.Add(0, 1e-3, WithWakeAck())
+	slow := g.Add(-time.Second, -1e-3)
+	if got := ahead.Now(); !got.Equal(refT.Add(2 * time.Second)) {
+		t.Errorf("Clock should start 2s ahead, got %s", got)
+	}
+
+	timer := fast.NewTimer(time.Hour)
+	g.Forward(time.Hour)
+	want := map[*Clock]time.Duration{
+		exact: time.Hour,
+		ahead: time.Hour + 2*time.Second,
+		fast:  time.Hour + 3600*time.Millisecond,
+		slow:  time.Hour - time.Second - 3600*time.Millisecond,
+	}
+	for i, c := range g.Clocks() {
+		if got := c.Since(refT); got != want[c] {
+			t.Errorf("Clock %d should be at %s, got %s", i, want[c], got)
+		}
+	}
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer of the fast clock should fire")
+	}
+
+	// A late clock drifts from the time it joins the group.
+	late := g.Add(0, 1e-3)
+	g.Set(refT.Add(2 * time.Hour))
+	if got := late.Since(refT); got != 2*time.Hour+3600*time.Millisecond {
+		t.Errorf("Late clock should drift by 3.6s in an hour, got %s", got)
+	}
+	if got := g.Now(); !got.Equal(refT.Add(2 * time.Hour)) {
+		t.Errorf("Group should be at %s, got %s", refT.Add(2*time.Hour), got)
+	}
+}
@@ -0,0 +1,129 @@
+// Package hlc implements hybrid logical clocks, as described in "Logical
+// Physical Clocks and Consistent Snapshots in Globally Distributed Databases"
+// by Kulkarni et al., on top of crown clocks: the physical time of each node
+// is the time of its crown clock, so that tests of distributed databases can
+// control it, and skew it with crown.ClockGroup.
+package hlc
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// ErrClockOffset is the error returned by Clock.Receive when the timestamp of
+// a message is too far ahead of the physical time of the receiver.
+var ErrClockOffset = errors.New("hlc: remote timestamp too far ahead")
+
+// Timestamp is a timestamp of a hybrid logical clock: the highest physical
+// time known to its clock, in nanoseconds since the Unix epoch, and a logical
+// counter ordering the events which share it.
+type Timestamp struct {
+	Wall    int64
+	Logical int32
+}
+
+// Time returns the physical time of t.
+func (t Timestamp) Time() time.Time {
+	return time.Unix(0, t.Wall)
+}
+
+// Compare returns -1 if t happened before u, +1 if it happened after, and 0
+// if they are equal.
+func (t Timestamp) Compare(u Timestamp) int {
+	switch {
+	case t.Wall < u.Wall || t.Wall == u.Wall && t.Logical < u.Logical:
+		return -1
+	case t == u:
+		return 0
+	}
+	return 1
+}
+
+// Before reports whether t happened before u.
+func (t Timestamp) Before(u Timestamp) bool {
+	return t.Compare(u) < 0
+}
+
+// String returns t formatted as its physical time, in RFC 3339 format, and its
+// logical counter, such as 2023-01-17T09:00:00Z/3.
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%s/%d", t.Time().UTC().Format(time.RFC3339Nano), t.Logical)
+}
+
+// Clock is a hybrid logical clock, reading the physical time from a crown
+// clock. It is created with New, and is safe for concurrent use.
+type Clock struct {
+	mu    sync.Mutex
+	clock *crown.Clock
+	last  Timestamp
+
+	// MaxOffset, if positive, is how far ahead of the physical time the
+	// timestamps received can be: Receive rejects those further ahead, whose
+	// sender has a clock out of bounds.
+	MaxOffset time.Duration
+}
+
+// New returns a hybrid logical clock whose physical time is the time of c.
+func New(c *crown.Clock) *Clock {
+	return &Clock{clock: c}
+}
+
+// Now returns the timestamp of a local event, or of a message sent.
+func (h *Clock) Now() Timestamp {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pt := h.clock.Now().UnixNano()
+	if pt > h.last.Wall {
+		h.last = Timestamp{Wall: pt}
+	} else {
+		h.last.Logical++
+	}
+	return h.last
+}
+
+// Send returns the timestamp of a message sent, like Now.
+func (h *Clock) Send() Timestamp {
+	return h.Now()
+}
+
+// Receive updates the clock with the timestamp m of a message received, and
+// returns the timestamp of its reception, which happens after both m and the
+// events of the clock so far. If m is ahead of the physical time by more than
+// MaxOffset, Receive leaves the clock unchanged, and returns an error wrapping
+// ErrClockOffset.
+func (h *Clock) Receive(m Timestamp) (Timestamp, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pt := h.clock.Now().UnixNano()
+	if h.MaxOffset > 0 && m.Wall-pt > int64(h.MaxOffset) {
+		return Timestamp{}, fmt.Errorf("%w: %s is %s ahead, more than %s", ErrClockOffset, m, time.Duration(m.Wall-pt), h.MaxOffset)
+	}
+	last := h.last
+	switch {
+	case pt > last.Wall && pt > m.Wall:
+		h.last = Timestamp{Wall: pt}
+	case last.Wall == m.Wall:
+		logical := last.Logical
+		if m.Logical > logical {
+			logical = m.Logical
+		}
+		h.last.Logical = logical + 1
+	case last.Wall > m.Wall:
+		h.last.Logical++
+	default:
+		h.last = Timestamp{Wall: m.Wall, Logical: m.Logical + 1}
+	}
+	return h.last, nil
+}
+
+// Last returns the timestamp of the latest event of the clock, without
+// creating a new one.
+func (h *Clock) Last() Timestamp {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.last
+}
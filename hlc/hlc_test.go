@@ -0,0 +1,58 @@
+package hlc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestClock(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-17T09:00:00Z")
+	nodes := crown.NewClockGroup(refT)
+	defer nodes.Close()
+	a := New(nodes.Add(time.Second, 0))
+	b := New(nodes.Add(0, 0))
+
+	t1 := a.Now()
+	t2 := a.Now()
+	if want := (Timestamp{Wall: refT.Add(time.Second).UnixNano(), Logical: 1}); t2 != want {
+		t.Errorf("Second event in the same nanosecond should be %s, got %s", want, t2)
+	}
+	if !t1.Before(t2) || t2.Compare(t1) != 1 || t1.Compare(t1) != 0 {
+		t.Errorf("%s should happen before %s", t1, t2)
+	}
+
+	// b is a second behind, and catches up with the message of a.
+	m := a.Send()
+	r, err := b.Receive(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Timestamp{Wall: m.Wall, Logical: 3}); r != want {
+		t.Errorf("Reception should be %s, got %s", want, r)
+	}
+	if got := b.Now(); got.Wall != m.Wall || got.Logical != 4 {
+		t.Errorf("b should keep the time of a until its physical time catches up, got %s", got)
+	}
+	nodes.Forward(2 * time.Second)
+	if got, want := b.Now(), (Timestamp{Wall: refT.Add(2 * time.Second).UnixNano()}); got != want {
+		t.Errorf("b should use its physical time again, got %s, want %s", got, want)
+	}
+	if got, _ := a.Receive(b.Last()); got.Wall != refT.Add(3*time.Second).UnixNano() || got.Logical != 0 {
+		t.Errorf("a should use its physical time, ahead of b, got %s", got)
+	}
+	if got := r.String(); got != "2023-01-17T09:00:01Z/3" {
+		t.Errorf("String should be 2023-01-17T09:00:01Z/3, got %s", got)
+	}
+
+	b.MaxOffset = 500 * time.Millisecond
+	last := b.Last()
+	if _, err := b.Receive(a.Now()); !errors.Is(err, ErrClockOffset) {
+		t.Errorf("Should reject a timestamp 1s ahead, got %v", err)
+	}
+	if b.Last() != last {
+		t.Errorf("Rejected timestamp should leave the clock unchanged")
+	}
+}
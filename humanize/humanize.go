@@ -0,0 +1,65 @@
+// Package humanize formats times relative to the current time of a crown
+// clock, such as "3 minutes ago" or "in 2 days", so that tests of rendered
+// relative timestamps are deterministic.
+package humanize
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+const (
+	day   = 24 * time.Hour
+	week  = 7 * day
+	month = 30 * day
+	year  = 365 * day
+)
+
+// units are the units of relative times, from the largest.
+var units = []struct {
+	size time.Duration
+	name string
+}{
+	{year, "year"},
+	{month, "month"},
+	{week, "week"},
+	{day, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+	{time.Second, "second"},
+}
+
+// Relative formats t relative to the current time of the clock c, see
+// Between.
+func Relative(c *crown.Clock, t time.Time) string {
+	return Between(c.Now(), t)
+}
+
+// Between formats t relative to now, in the largest unit in which the
+// distance between them is at least one, rounded down: "3 minutes ago" for a
+// past time, "in 2 days" for a future one. Months count 30 days and years 365
+// days. Times less than a second away from now are formatted as "now".
+func Between(now, t time.Time) string {
+	d := now.Sub(t)
+	past := d >= 0
+	if !past {
+		d = -d
+	}
+	for _, unit := range units {
+		if d < unit.size {
+			continue
+		}
+		n := int64(d / unit.size)
+		s := strconv.FormatInt(n, 10) + " " + unit.name
+		if n > 1 {
+			s += "s"
+		}
+		if past {
+			return s + " ago"
+		}
+		return "in " + s
+	}
+	return "now"
+}
@@ -0,0 +1,42 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestBetween(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-28T09:00:00Z")
+	for _, tc := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "now"},
+		{-999 * time.Millisecond, "now"},
+		{-time.Second, "1 second ago"},
+		{-3*time.Minute - 59*time.Second, "3 minutes ago"},
+		{time.Hour, "in 1 hour"},
+		{2*day + 23*time.Hour, "in 2 days"},
+		{-15 * day, "2 weeks ago"},
+		{90 * day, "in 3 months"},
+		{-800 * day, "2 years ago"},
+	} {
+		if got := Between(refT, refT.Add(tc.d)); got != tc.want {
+			t.Errorf("Between(%v) should be %q, got %q", tc.d, tc.want, got)
+		}
+	}
+}
+
+func TestRelative(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-28T09:00:00Z")
+	clock := crown.NewClock(refT)
+	if got := Relative(clock, refT); got != "now" {
+		t.Errorf("Should be %q, got %q", "now", got)
+	}
+	clock.Forward(3 * time.Minute)
+	if got := Relative(clock, refT); got != "3 minutes ago" {
+		t.Errorf("Should be %q, got %q", "3 minutes ago", got)
+	}
+}
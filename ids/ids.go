@@ -0,0 +1,154 @@
+// Package ids generates time-ordered identifiers, ULIDs and UUIDv7s, from a
+// crown clock and a seeded entropy source, so that the identifiers created
+// during a test are stable and can be asserted on.
+package ids
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// ULID is a Universally Unique Lexicographically Sortable Identifier: a
+// 48-bit timestamp in milliseconds followed by 80 bits of entropy.
+type ULID [16]byte
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// String returns the canonical 26-character representation of id.
+func (id ULID) String() string {
+	// 128 bits encoded 5 bits at a time, the first character holding 3.
+	var out [26]byte
+	var acc uint64 // bits not encoded yet, aligned right
+	var n uint
+	i := len(out) - 1
+	for j := len(id) - 1; j >= 0; j-- {
+		acc |= uint64(id[j]) << n
+		n += 8
+		for n >= 5 {
+			out[i] = crockford[acc&31]
+			acc >>= 5
+			n -= 5
+			i--
+		}
+	}
+	out[0] = crockford[acc&31]
+	return string(out[:])
+}
+
+// Time returns the timestamp of id.
+func (id ULID) Time() time.Time {
+	return timeOf(id[:])
+}
+
+// UUID is a version 7 UUID: a 48-bit timestamp in milliseconds followed by
+// the version, variant, and 74 bits of entropy.
+type UUID [16]byte
+
+// String returns the canonical representation of id, such as
+// 01850e6c-8c00-7a3b-8f4e-1c2d3e4f5a6b.
+func (id UUID) String() string {
+	var out [36]byte
+	hex.Encode(out[0:8], id[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], id[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], id[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], id[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], id[10:])
+	return string(out[:])
+}
+
+// Time returns the timestamp of id.
+func (id UUID) Time() time.Time {
+	return timeOf(id[:])
+}
+
+func timeOf(b []byte) time.Time {
+	var ms int64
+	for _, c := range b[:6] {
+		ms = ms<<8 | int64(c)
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+// Generator generates identifiers timestamped by a clock. The identifiers it
+// generates are strictly increasing: those generated in the same millisecond
+// of the clock have their entropy incremented rather than drawn anew, the ULIDs
+// and the UUIDs being increasing independently of each other. A Generator is
+// safe for concurrent use.
+type Generator struct {
+	clock *crown.Clock
+	mu    sync.Mutex
+	rand  *rand.Rand
+	last  map[byte][16]byte // last identifier generated, by kind: 'u' for ULID, '7' for UUID
+}
+
+// NewGenerator returns a generator timestamping identifiers with the time of
+// c, and drawing their entropy from a pseudo-random source seeded with seed.
+func NewGenerator(c *crown.Clock, seed int64) *Generator {
+	return &Generator{clock: c, rand: rand.New(rand.NewSource(seed)), last: make(map[byte][16]byte)}
+}
+
+// ULID returns a new ULID.
+func (g *Generator) ULID() ULID {
+	return ULID(g.next('u'))
+}
+
+// UUIDv7 returns a new version 7 UUID.
+func (g *Generator) UUIDv7() UUID {
+	return UUID(g.next('7'))
+}
+
+// next returns the next identifier of the given kind.
+func (g *Generator) next(kind byte) [16]byte {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var id [16]byte
+	ms := g.clock.Now().UnixMilli()
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+	if last, ok := g.last[kind]; ok && string(id[:6]) == string(last[:6]) {
+		copy(id[6:], last[6:])
+		increment(id[6:], kind)
+	} else {
+		g.rand.Read(id[6:])
+	}
+	if kind == '7' {
+		id[6] = id[6]&0x0f | 0x70 // version 7
+		id[8] = id[8]&0x3f | 0x80 // RFC 9562 variant
+	}
+	g.last[kind] = id
+	return id
+}
+
+// increment adds one to the entropy b of an identifier of the given kind,
+// skipping the version and variant bits of UUIDs. It panics on overflow,
+// which takes 2^74 identifiers in the same millisecond.
+func increment(b []byte, kind byte) {
+	for i := len(b) - 1; i >= 0; i-- {
+		mask := byte(0xff)
+		if kind == '7' {
+			switch i {
+			case 0:
+				mask = 0x0f
+			case 2:
+				mask = 0x3f
+			}
+		}
+		v := b[i]&mask + 1
+		b[i] = b[i]&^mask | v&mask
+		if v&mask != 0 {
+			return
+		}
+	}
+	panic("ids: entropy overflow within a millisecond")
+}
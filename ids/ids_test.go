@@ -0,0 +1,81 @@
+package ids
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestULID(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-23T09:00:00Z")
+	clock := crown.NewClock(refT)
+	g := NewGenerator(clock, 1)
+	first := g.ULID()
+	if got := NewGenerator(crown.NewClock(refT), 1).ULID(); got != first {
+		t.Errorf("Same seed should generate %v, got %v", first, got)
+	}
+	if !first.Time().Equal(refT) {
+		t.Errorf("Should be timestamped %q, got %q", refT, first.Time())
+	}
+	// Timestamp of the example of the ULID specification.
+	if s := NewGenerator(crown.NewClock(time.UnixMilli(1469918176385)), 1).ULID().String(); s[:10] != "01ARYZ6S41" {
+		t.Errorf("Should encode the timestamp as 01ARYZ6S41, got %s", s)
+	}
+	if s := first.String(); len(s) != 26 || s[:10] != "01GMZ3BVM0" {
+		t.Errorf("Should encode the timestamp as 01GMZ3BVM0, got %s", s)
+	}
+
+	second := g.ULID()
+	if second.String() <= first.String() {
+		t.Errorf("ULIDs of the same millisecond should increase: %v then %v", first, second)
+	}
+	clock.Forward(time.Millisecond)
+	if third := g.ULID(); third.String() <= second.String() || !third.Time().Equal(clock.Now()) {
+		t.Errorf("ULID should increase with the clock: %v then %v", second, third)
+	}
+}
+
+func TestUUIDv7(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-23T09:00:00Z")
+	g := NewGenerator(crown.NewClock(refT), 1)
+	first := g.UUIDv7()
+	s := first.String()
+	if len(s) != 36 || s[:13] != "01853e35-ee80" || s[14] != '7' || (s[19] < '8' || s[19] > 'b') {
+		t.Errorf("Should be a UUIDv7 timestamped 01853e35-ee80, got %s", s)
+	}
+	if !first.Time().Equal(refT) {
+		t.Errorf("Should be timestamped %q, got %q", refT, first.Time())
+	}
+	prev := first
+	for i := 0; i < 1000; i++ {
+		id := g.UUIDv7()
+		if id.String() <= prev.String() || id[6]>>4 != 7 || id[8]>>6 != 2 {
+			t.Fatalf("UUIDs should increase and keep version and variant: %v then %v", prev, id)
+		}
+		prev = id
+	}
+}
+
+func TestInterleavedKinds(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-11-14T09:00:00Z")
+	for seed := int64(1); seed <= 20; seed++ {
+		g := NewGenerator(crown.NewClock(refT), seed)
+		ulid, uuid := g.ULID(), g.UUIDv7()
+		for i := 0; i < 10; i++ {
+			nextULID, nextUUID := g.ULID(), g.UUIDv7()
+			if nextULID.String() <= ulid.String() || nextUUID.String() <= uuid.String() {
+				t.Fatalf("Seed %d: identifiers should increase: %v then %v, %v then %v", seed, ulid, nextULID, uuid, nextUUID)
+			}
+			ulid, uuid = nextULID, nextUUID
+		}
+	}
+}
+
+func TestIncrementSkipsVersionBits(t *testing.T) {
+	b := []byte{0x70, 0xff, 0xbf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	increment(b, '7')
+	if want := []byte{0x71, 0x00, 0x80, 0, 0, 0, 0, 0, 0, 0}; string(b) != string(want) {
+		t.Errorf("Should carry over the version and variant bits to % x, got % x", want, b)
+	}
+}
@@ -0,0 +1,27 @@
+package crown
+
+import "time"
+
+// WithTimerJitter makes the timers of the clock, those of NewTimer, After and
+// AfterFunc, fire late by a random delay between 0 and max, drawn from the
+// random numbers of the clock, see Rand, when they are started or reset. This
+// exposes the code which assumes that timers fire exactly at their deadline,
+// while a real timer is always late by some scheduling latency. Like with the
+// time package, timers never fire early. Seeding the clock with WithRandSeed
+// makes the delays reproducible. WithTimerJitter panics if max is negative.
+func WithTimerJitter(max time.Duration) Option {
+	if max < 0 {
+		panic("crown: negative jitter for WithTimerJitter")
+	}
+	return func(c *Clock) {
+		c.jitter = max
+	}
+}
+
+// timerJitter returns the delay after its deadline at which a timer fires.
+func (c *Clock) timerJitter() time.Duration {
+	if c.jitter == 0 {
+		return 0
+	}
+	return time.Duration(c.Rand().Int63n(int64(c.jitter) + 1))
+}
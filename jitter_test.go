@@ -0,0 +1,40 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerJitter(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-14T09:00:00Z")
+	fired := func(seed int64) []time.Duration {
+		c := NewClock(refT, WithRandSeed(seed), WithTimerJitter(10*time.Millisecond), WithStepwiseForward(), WithWakeAck())
+		var timers []*Timer
+		for i := 0; i < 20; i++ {
+			timers = append(timers, c.NewTimer(time.Second))
+		}
+		c.Forward(2 * time.Second)
+		var delays []time.Duration
+		for _, timer := range timers {
+			delays = append(delays, (<-timer.C).Sub(refT))
+		}
+		return delays
+	}
+	got := fired(7)
+	distinct := make(map[time.Duration]bool)
+	for _, d := range got {
+		if d < time.Second || d > time.Second+10*time.Millisecond {
+			t.Errorf("Timer should fire within 10ms after its deadline, got %s", d)
+		}
+		distinct[d] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("Timers should fire at different times, got %v", got)
+	}
+	again := fired(7)
+	for i := range got {
+		if got[i] != again[i] {
+			t.Fatalf("Should reproduce the delays from the seed, got %v and %v", got, again)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package jwt mints and validates HS256 JSON Web Tokens whose time claims,
+// exp, nbf and iat, are checked against a crown clock, so that the expiry
+// and clock-skew tolerance paths of authentication code can be exercised by
+// advancing the clock.
+//
+// For code using a JWT library, TimeFunc adapts the clock to the time hooks
+// of such libraries, like jwt.WithTimeFunc of github.com/golang-jwt/jwt/v5.
+package jwt
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Errors returned by Parse. The time errors are wrapped with the details of
+// the failed check.
+var (
+	ErrMalformed      = errors.New("jwt: malformed token")
+	ErrSignature      = errors.New("jwt: invalid signature")
+	ErrExpired        = errors.New("jwt: token expired")
+	ErrNotYetValid    = errors.New("jwt: token not valid yet")
+	ErrIssuedInFuture = errors.New("jwt: token issued in the future")
+	ErrAlgorithm      = errors.New("jwt: unsupported algorithm")
+)
+
+// Claims are the claims of a token.
+type Claims map[string]any
+
+// TimeFunc returns the Now method of c, to be passed to the time hooks of JWT
+// libraries.
+func TimeFunc(c *crown.Clock) func() time.Time {
+	return c.Now
+}
+
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Mint returns a token signed with key, holding claims and time claims set
+// from the clock c: iat and nbf are set to its current time, and exp to ttl
+// later if ttl is positive. The time claims already in claims are kept.
+func Mint(c *crown.Clock, key []byte, ttl time.Duration, claims Claims) (string, error) {
+	now := c.Now()
+	all := Claims{"iat": now.Unix(), "nbf": now.Unix()}
+	if ttl > 0 {
+		all["exp"] = now.Add(ttl).Unix()
+	}
+	for k, v := range claims {
+		all[k] = v
+	}
+	payload, err := json.Marshal(all)
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + sign(key, signed), nil
+}
+
+func sign(key []byte, signed string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Option configures Parse.
+type Option func(*parser)
+
+// WithLeeway tolerates a clock skew of d when checking the time claims.
+func WithLeeway(d time.Duration) Option {
+	return func(p *parser) {
+		p.leeway = d
+	}
+}
+
+type parser struct {
+	leeway time.Duration
+}
+
+// Parse verifies the signature of token with key and checks its time claims
+// against the current time of the clock c: it fails with ErrExpired at or
+// after exp, with ErrNotYetValid before nbf and with ErrIssuedInFuture before
+// iat, give or take the leeway. It returns the claims of the token, numbers
+// being decoded as json.Number.
+func Parse(c *crown.Clock, key []byte, token string, opts ...Option) (Claims, error) {
+	var p parser
+	for _, opt := range opts {
+		opt(&p)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+	}
+	if err := decode(parts[0], &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Alg != "HS256" {
+		return nil, fmt.Errorf("%w %q", ErrAlgorithm, hdr.Alg)
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(key, parts[0]+"."+parts[1]))) {
+		return nil, ErrSignature
+	}
+	var claims Claims
+	if err := decode(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	now := c.Now()
+	checks := []struct {
+		claim string
+		err   error
+		fails func(t time.Time) bool
+	}{
+		{"exp", ErrExpired, func(t time.Time) bool { return !now.Before(t.Add(p.leeway)) }},
+		{"nbf", ErrNotYetValid, func(t time.Time) bool { return now.Before(t.Add(-p.leeway)) }},
+		{"iat", ErrIssuedInFuture, func(t time.Time) bool { return now.Before(t.Add(-p.leeway)) }},
+	}
+	for _, check := range checks {
+		v, ok := claims[check.claim]
+		if !ok {
+			continue
+		}
+		t, err := numericDate(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, check.claim, err)
+		}
+		if check.fails(t) {
+			return nil, fmt.Errorf("%w: %s is %s, now is %s", check.err, check.claim,
+				t.Format(time.RFC3339), now.Format(time.RFC3339))
+		}
+	}
+	return claims, nil
+}
+
+func decode(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+// numericDate converts a NumericDate claim, in seconds since the epoch.
+func numericDate(v any) (time.Time, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, fmt.Errorf("not a number: %v", v)
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(f*float64(time.Second))), nil
+}
@@ -0,0 +1,60 @@
+package jwt
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestExpiry(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-24T09:00:00Z")
+	clock := crown.NewClock(refT)
+	key := []byte("secret")
+	token, err := Mint(clock, key, time.Hour, Claims{"sub": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := Parse(clock, key, token)
+	if err != nil {
+		t.Fatalf("Fresh token should be valid, got %v", err)
+	}
+	if claims["sub"] != "alice" {
+		t.Errorf("Should keep the claims, got %v", claims)
+	}
+	if _, err := Parse(clock, []byte("other"), token); !errors.Is(err, ErrSignature) {
+		t.Errorf("Should check the signature, got %v", err)
+	}
+
+	clock.Forward(time.Hour - time.Second)
+	if _, err := Parse(clock, key, token); err != nil {
+		t.Errorf("Token should be valid until it expires, got %v", err)
+	}
+	clock.Forward(time.Second)
+	if _, err := Parse(clock, key, token); !errors.Is(err, ErrExpired) {
+		t.Errorf("Token should expire after an hour, got %v", err)
+	}
+	if _, err := Parse(clock, key, token, WithLeeway(time.Minute)); err != nil {
+		t.Errorf("Leeway should tolerate the skew, got %v", err)
+	}
+}
+
+func TestNotYetValid(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-24T09:00:00Z")
+	issuer := crown.NewClock(refT.Add(30 * time.Second)) // ahead of the verifier
+	verifier := crown.NewClock(refT)
+	key := []byte("secret")
+	token, _ := Mint(issuer, key, time.Hour, nil)
+
+	if _, err := Parse(verifier, key, token); !errors.Is(err, ErrNotYetValid) {
+		t.Errorf("Should reject a token from the future, got %v", err)
+	}
+	if _, err := Parse(verifier, key, token, WithLeeway(time.Minute)); err != nil {
+		t.Errorf("Leeway should tolerate the skew, got %v", err)
+	}
+	if got := TimeFunc(verifier)(); !got.Equal(refT) {
+		t.Errorf("TimeFunc should return the clock time, got %q", got)
+	}
+}
@@ -0,0 +1,35 @@
+package crown
+
+import "time"
+
+// WithProfilerLabels makes the goroutines blocked on the clock, including
+// those internal to its tickers, carry pprof labels for the time of their
+// wait, so that goroutine profiles taken during a stuck test show which
+// simulated waits are outstanding:
+//
+//   - crown.clock: the name of the clock,
+//   - crown.wait: what waits, one of sleep and ticker,
+//   - crown.deadline: the deadline of the wait,
+//   - crown.trace: the trace identifier of the wait, if any (see
+//     WithTraceContext).
+//
+// Labels are not supported on TinyGo, where the option has no effect.
+func WithProfilerLabels(name string) Option {
+	return func(c *Clock) {
+		c.name = name
+		c.labels = true
+	}
+}
+
+// profilerLabels returns the label pairs of the wait of handler.
+func (c *Clock) profilerLabels(handler *sleepHandler) []string {
+	labels := []string{
+		"crown.clock", c.name,
+		"crown.wait", handler.kind,
+		"crown.deadline", handler.deadline.Format(time.RFC3339Nano),
+	}
+	if handler.trace != "" {
+		labels = append(labels, "crown.trace", handler.trace)
+	}
+	return labels
+}
@@ -0,0 +1,14 @@
+//go:build !tinygo
+
+package crown
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// doWithLabels calls f with the goroutine labeled with the label pairs of
+// labels, added to those of ctx.
+func doWithLabels(ctx context.Context, labels []string, f func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(labels...), f)
+}
@@ -0,0 +1,42 @@
+package crown
+
+import (
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfilerLabels(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-18T09:00:00Z")
+	clock := NewClock(refT, WithProfilerLabels("labeled"))
+	ticker := clock.NewTicker(time.Minute)
+	defer ticker.Stop()
+	go clock.Sleep(time.Hour)
+
+	want := []string{
+		`"crown.clock":"labeled"`,
+		`"crown.wait":"sleep"`,
+		`"crown.deadline":"2022-12-18T10:00:00Z"`,
+	}
+	if !inlineTickers {
+		want = append(want, `"crown.wait":"ticker"`, `"crown.deadline":"2022-12-18T09:01:00Z"`)
+	}
+	var profile string
+	for try := 0; try < 100; try++ {
+		var b strings.Builder
+		pprof.Lookup("goroutine").WriteTo(&b, 1)
+		profile = b.String()
+		missing := false
+		for _, w := range want {
+			missing = missing || !strings.Contains(profile, w)
+		}
+		if !missing {
+			clock.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	clock.Close()
+	t.Errorf("Goroutine profile should contain the labels %v, got:\n%s", want, profile)
+}
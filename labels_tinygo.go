@@ -0,0 +1,10 @@
+//go:build tinygo
+
+package crown
+
+import "context"
+
+// doWithLabels calls f: TinyGo does not support profiler labels.
+func doWithLabels(ctx context.Context, _ []string, f func(context.Context)) {
+	f(ctx)
+}
@@ -0,0 +1,253 @@
+package crown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pkgPath is the import path of the package, as seen in stack traces.
+var pkgPath = reflect.TypeOf((*Clock)(nil)).Elem().PkgPath()
+
+// LeakIgnoreFunctions returns the names of the functions running the
+// goroutines internal to the package: those backing tickers, auto-advance,
+// clocks following the wall time, watchdogs, and contexts whose parent can be
+// done. They are meant to be passed to go.uber.org/goleak, so that its checks
+// focus on the goroutines of the code under test:
+//
+//	var opts []goleak.Option
+//	for _, f := range crown.LeakIgnoreFunctions() {
+//		opts = append(opts, goleak.IgnoreAnyFunction(f))
+//	}
+//	defer goleak.VerifyNone(t, opts...)
+//
+// Pending timers and tickers are better reported by Clock.CheckLeaks.
+func LeakIgnoreFunctions() []string {
+	return []string{
+		pkgPath + ".(*Clock).runTicker",
+		pkgPath + ".(*Clock).autoAdvance",
+		pkgPath + ".(*Clock).followWall",
+		pkgPath + ".(*watchdog).run",
+		pkgPath + ".(*Context).watchParent",
+	}
+}
+
+// LeakIgnoreCurrent records the goroutines currently running, and returns a
+// function reporting the goroutines started since then and still running,
+// like goleak.VerifyNone with goleak.IgnoreCurrent, for the tests which cannot
+// depend on goleak. The goroutines internal to the package, see
+// LeakIgnoreFunctions, are not reported. The returned function waits up to a
+// second for the goroutines to exit, then returns an error giving their
+// stacks, or nil if there is none:
+//
+//	check := crown.LeakIgnoreCurrent()
+//	defer func() {
+//		if err := check(); err != nil {
+//			t.Error(err)
+//		}
+//	}()
+//
+// The waits left pending by the leaked goroutines are better reported by
+// Clock.CheckLeaks.
+func LeakIgnoreCurrent() (check func() error) {
+	current := make(map[uint64]bool)
+	for _, g := range goroutines() {
+		current[g.id] = true
+	}
+	return func() error {
+		self := goroutineID()
+		var leaked []goroutine
+		for wait, deadline := time.Millisecond, time.Now().Add(time.Second); ; wait *= 2 {
+			leaked = leaked[:0]
+			for _, g := range goroutines() {
+				if !current[g.id] && g.id != self && !g.internal() {
+					leaked = append(leaked, g)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(wait)
+		}
+		if len(leaked) == 0 {
+			return nil
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "crown: %d goroutine(s) leaked", len(leaked))
+		for _, g := range leaked {
+			fmt.Fprintf(&b, "\n\n%s", g.stack)
+		}
+		return errors.New(b.String())
+	}
+}
+
+// goroutine is a goroutine listed by runtime.Stack.
+type goroutine struct {
+	id    uint64
+	stack string
+}
+
+// goroutines returns the goroutines currently running.
+func goroutines() []goroutine {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	var all []goroutine
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		header := strings.TrimPrefix(stack, "goroutine ")
+		if i := strings.IndexByte(header, ' '); i > 0 {
+			header = header[:i]
+		}
+		if id, err := strconv.ParseUint(header, 10, 64); err == nil {
+			all = append(all, goroutine{id: id, stack: stack})
+		}
+	}
+	return all
+}
+
+// internal reports whether the goroutine is internal to the package.
+func (g goroutine) internal() bool {
+	for _, f := range LeakIgnoreFunctions() {
+		if strings.Contains(g.stack, "\n"+f+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckLeaks returns an error describing the waits still pending on the
+// clock, or nil if there is none. If the clock was created with
+// WithStackCapture, each wait is attributed to the stack which registered it.
+// It is typically called at the end of a test, along with goleak:
+//
+//	if err := clock.CheckLeaks(); err != nil {
+//		t.Error(err)
+//	}
+func (c *Clock) CheckLeaks() error {
+	pending := c.pendingHandlers()
+	if len(pending) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "crown: %d wait(s) still pending at %s", len(pending), c.Now().Format(time.RFC3339Nano))
+	for _, handler := range pending {
+		fmt.Fprintf(&b, "\n\n%s %d until %s", handler.kind, handler.id, handler.deadline.Format(time.RFC3339Nano))
+		if handler.stack == nil {
+			b.WriteString(" (use WithStackCapture to record its origin)")
+			continue
+		}
+		fmt.Fprintf(&b, ", registered by goroutine %d at:\n", handler.goid)
+		writeStack(&b, handler.stack)
+	}
+	return errors.New(b.String())
+}
+
+// PendingWait describes a wait pending on a clock.
+type PendingWait struct {
+	// ID identifies the wait among those of the clock, like Event.ID.
+	ID int64
+	// Kind tells what waits: "sleep", "timer", "ticker" or "context".
+	Kind string
+	// Trace is the trace identifier recorded along with the wait, see
+	// WithTraceContext.
+	Trace string
+	// Start is the time of the clock when the wait was registered.
+	Start time.Time
+	// Age is how long the clock moved since the wait was registered.
+	Age time.Duration
+	// Deadline is the time of the clock when the wait is due.
+	Deadline time.Time
+	// Stack is the call stack which registered the wait, in the format of
+	// panics, or the empty string unless the clock was created with
+	// WithStackCapture.
+	Stack string
+	// Goroutine is the ID of the goroutine which registered the wait, as
+	// printed in stack traces, or 0 unless the clock was created with
+	// WithStackCapture. For sleeps, it is the goroutine still waiting.
+	Goroutine uint64
+}
+
+// Pending returns the waits currently pending on the clock, sorted by
+// deadline, then by registration order.
+func (c *Clock) Pending() []PendingWait {
+	now := c.Now()
+	handlers := c.pendingHandlers()
+	pending := make([]PendingWait, len(handlers))
+	for i, handler := range handlers {
+		pending[i] = PendingWait{
+			ID:        int64(handler.id),
+			Kind:      handler.kind,
+			Trace:     handler.trace,
+			Start:     handler.start,
+			Age:       now.Sub(handler.start),
+			Deadline:  handler.deadline,
+			Goroutine: handler.goid,
+		}
+		if handler.stack != nil {
+			var b strings.Builder
+			writeStack(&b, handler.stack)
+			pending[i].Stack = b.String()
+		}
+	}
+	return pending
+}
+
+// pendingHandlers returns the registered handlers, sorted by deadline, then by
+// registration order.
+func (c *Clock) pendingHandlers() []*sleepHandler {
+	c.waitersMu.Lock()
+	pending := c.waits().handlers()
+	c.waitersMu.Unlock()
+	sortHandlers(pending)
+	return pending
+}
+
+// callers returns the current call stack if the clock captures stacks, and
+// nil otherwise.
+func (c *Clock) callers() []uintptr {
+	if !c.stacks {
+		return nil
+	}
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+// goroutineID returns the ID of the current goroutine, parsed from the header
+// of its stack trace, or 0 if it cannot be parsed.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
+
+// writeStack writes the stack pcs in the format of panics, omitting its
+// first frames when they belong to the methods of Clock.
+func writeStack(b *strings.Builder, pcs []uintptr) {
+	frames := runtime.CallersFrames(pcs)
+	internal := true
+	for more := true; more; {
+		var frame runtime.Frame
+		frame, more = frames.Next()
+		if internal && strings.HasPrefix(frame.Function, pkgPath+".(*Clock).") {
+			continue
+		}
+		internal = false
+		fmt.Fprintf(b, "%s()\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+}
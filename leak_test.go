@@ -0,0 +1,171 @@
+package crown
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckLeaks(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithStackCapture())
+	if err := clock.CheckLeaks(); err != nil {
+		t.Errorf("Should not report anything, got %v instead", err)
+	}
+
+	timer := clock.NewTimer(time.Minute)
+	defer timer.Stop()
+	waitForSleepers(t, clock, 1, 10)
+
+	err := clock.CheckLeaks()
+	if err == nil {
+		t.Fatalf("Should report the pending timer")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "crown.TestCheckLeaks()") {
+		t.Errorf("Should attribute the timer to the test, got:\n%s", msg)
+	}
+	if strings.Contains(msg, "NewTimer") {
+		t.Errorf("Should not report clock internals, got:\n%s", msg)
+	}
+}
+
+func TestLeakIgnoreFunctions(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	for _, tc := range []struct {
+		name string
+		fn   string // function of LeakIgnoreFunctions running
+		run  func(t *testing.T) (stop func())
+	}{{
+		name: "ticker",
+		fn:   ".(*Clock).runTicker",
+		run: func(t *testing.T) func() {
+			if inlineTickers {
+				t.Skip("inline tickers run without goroutines")
+			}
+			clock := NewClock(refT)
+			ticker := clock.NewTicker(time.Minute)
+			return ticker.Stop
+		},
+	}, {
+		name: "WithAutoAdvance",
+		fn:   ".(*Clock).autoAdvance",
+		run: func(t *testing.T) func() {
+			clock := NewClock(refT, WithAutoAdvance())
+			return func() { clock.Close() }
+		},
+	}, {
+		name: "WithTimeScale",
+		fn:   ".(*Clock).followWall",
+		run: func(t *testing.T) func() {
+			clock := NewClock(refT, WithTimeScale(60))
+			return func() { clock.Close() }
+		},
+	}, {
+		name: "NewWallClock",
+		fn:   ".(*Clock).followWall",
+		run: func(t *testing.T) func() {
+			clock := NewWallClock()
+			return func() { clock.Close() }
+		},
+	}, {
+		name: "WithWatchdog",
+		fn:   ".(*watchdog).run",
+		run: func(t *testing.T) func() {
+			clock := NewClock(refT, WithWatchdog(time.Minute, func(*Clock) {}))
+			return func() { clock.Close() }
+		},
+	}, {
+		name: "WithDeadline",
+		fn:   ".(*Context).watchParent",
+		run: func(t *testing.T) func() {
+			clock := NewClock(refT)
+			parent, cancelParent := context.WithCancel(context.Background())
+			_, cancel := clock.WithTimeout(parent, time.Minute)
+			return func() {
+				cancel()
+				cancelParent()
+			}
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			check := LeakIgnoreCurrent()
+			stop := tc.run(t)
+			defer stop()
+			if !running(pkgPath + tc.fn) {
+				t.Errorf("No goroutine runs %s", pkgPath+tc.fn)
+			}
+			if err := check(); err != nil {
+				t.Errorf("Should ignore the goroutines of the package, got %v", err)
+			}
+		})
+	}
+}
+
+// running reports whether a goroutine runs the function f.
+func running(f string) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		for _, g := range goroutines() {
+			if strings.Contains(g.stack, "\n"+f+"(") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestLeakIgnoreCurrent(t *testing.T) {
+	before := make(chan struct{})
+	defer close(before)
+	go func() { <-before }()
+
+	check := LeakIgnoreCurrent()
+	if err := check(); err != nil {
+		t.Errorf("Should ignore the goroutines already running, got %v", err)
+	}
+	after := make(chan struct{})
+	go leakingFunction(after)
+	err := check()
+	close(after)
+	if err == nil || !strings.Contains(err.Error(), "leakingFunction") {
+		t.Errorf("Should report the goroutine started since, got %v", err)
+	}
+	if err := check(); err != nil {
+		t.Errorf("Should not report the goroutines which exited, got %v", err)
+	}
+}
+
+func leakingFunction(stop chan struct{}) {
+	<-stop
+}
+
+func TestPending(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithStackCapture())
+	late := clock.NewTimer(time.Hour)
+	defer late.Stop()
+	clock.Forward(time.Minute)
+	early := clock.NewTimer(time.Minute)
+	defer early.Stop()
+
+	pending := clock.Pending()
+	if len(pending) != 2 {
+		t.Fatalf("Should report 2 pending waits, got %d", len(pending))
+	}
+	if want := refT.Add(2 * time.Minute); !pending[0].Deadline.Equal(want) || !pending[0].Start.Equal(refT.Add(time.Minute)) {
+		t.Errorf("First wait should be due at %q, got %+v", want, pending[0])
+	}
+	if want := refT.Add(time.Hour); !pending[1].Deadline.Equal(want) || !pending[1].Start.Equal(refT) {
+		t.Errorf("Second wait should be due at %q, got %+v", want, pending[1])
+	}
+	if pending[1].Age != time.Minute || pending[0].Age != 0 {
+		t.Errorf("Should report the ages of the waits, got %s and %s", pending[0].Age, pending[1].Age)
+	}
+	if pending[0].Goroutine == 0 || pending[0].Goroutine != pending[1].Goroutine {
+		t.Errorf("Should report the goroutine of the test, got %d and %d", pending[0].Goroutine, pending[1].Goroutine)
+	}
+	if !strings.Contains(pending[0].Stack, "crown.TestPending()") {
+		t.Errorf("Should attribute the wait to the test, got:\n%s", pending[0].Stack)
+	}
+}
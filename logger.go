@@ -0,0 +1,55 @@
+package crown
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Logger receives the debug logs of a clock. It is satisfied by *slog.Logger.
+type Logger interface {
+	Debug(msg string, args ...any)
+}
+
+// LogfLogger adapts a printf-like function, such as the Logf method of
+// testing.T, to a Logger. The arguments are formatted as key=value pairs
+// following the message:
+//
+//	clock := crown.NewClock(start, crown.WithLogger(crown.LogfLogger(t.Logf)))
+type LogfLogger func(format string, args ...any)
+
+// Debug implements Logger.
+func (f LogfLogger) Debug(msg string, args ...any) {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	f("%s", b.String())
+}
+
+// WithLogger makes the clock log every advance, registration, fire and
+// cancellation to logger, at debug level, with the time of the clock. A
+// *slog.Logger can be passed directly:
+//
+//	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+//	clock := crown.NewClock(start, crown.WithLogger(logger))
+func WithLogger(logger Logger) Option {
+	return WithObserver(func(e Event) {
+		now := e.Time.Format(time.RFC3339Nano)
+		switch e.Kind {
+		case EventAdvance:
+			logger.Debug("crown: advance", "time", now, "by", e.Advance)
+		case EventRegister:
+			logger.Debug("crown: register", "time", now, "wait", e.Wait, "id", e.ID, "deadline", e.Deadline.Format(time.RFC3339Nano))
+		case EventFire:
+			logger.Debug("crown: fire", "time", now, "wait", e.Wait, "id", e.ID, "waited", e.Waited())
+		case EventCancel:
+			logger.Debug("crown: cancel", "time", now, "wait", e.Wait, "id", e.ID, "waited", e.Waited(), "err", e.Err)
+		}
+	})
+}
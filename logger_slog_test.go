@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package crown
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogLogger(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-18T09:00:00Z")
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	clock := NewClock(refT, WithWakeAck(), WithLogger(logger))
+	go clock.Sleep(time.Second)
+	clock.BlockUntil(1)
+	clock.Forward(time.Second)
+
+	out := buf.String()
+	for _, want := range []string{
+		`level=DEBUG msg="crown: register" time=2022-12-18T09:00:00Z wait=sleep id=1 deadline=2022-12-18T09:00:01Z`,
+		`level=DEBUG msg="crown: advance" time=2022-12-18T09:00:01Z by=1s`,
+		`level=DEBUG msg="crown: fire" time=2022-12-18T09:00:01Z wait=sleep id=1 waited=1s`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Should log %s, got:\n%s", want, out)
+		}
+	}
+}
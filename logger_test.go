@@ -0,0 +1,40 @@
+package crown
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-18T09:00:00Z")
+	var mu sync.Mutex
+	var lines []string
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		lines = append(lines, fmt.Sprintf(format, args...))
+		mu.Unlock()
+	}
+	clock := NewClock(refT, WithWakeAck(), WithLogger(LogfLogger(logf)))
+	timer := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Minute)
+	clock.Forward(2 * time.Second)
+	<-timer.C
+	clock.CancelAll()
+	stopped.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"crown: register time=2022-12-18T09:00:00Z wait=timer id=1 deadline=2022-12-18T09:00:01Z",
+		"crown: register time=2022-12-18T09:00:00Z wait=timer id=2 deadline=2022-12-18T09:01:00Z",
+		"crown: advance time=2022-12-18T09:00:02Z by=2s",
+		"crown: fire time=2022-12-18T09:00:02Z wait=timer id=1 waited=2s",
+		"crown: cancel time=2022-12-18T09:00:02Z wait=timer id=2 waited=2s err=crown: wait canceled",
+	}
+	if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("Should log:\n%s\ngot:\n%s", strings.Join(want, "\n"), got)
+	}
+}
@@ -0,0 +1,207 @@
+// Package logical implements Lamport clocks and vector clocks, to build and
+// test causality-tracking protocols in simulations. Their timestamps can be
+// stamped with the time of a crown clock, to relate the logical order of the
+// events with the simulated time.
+package logical
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// LamportStamp is a timestamp of a Lamport clock.
+type LamportStamp struct {
+	Counter uint64
+	Time    time.Time // time of the crown clock, if any, at the event
+}
+
+// Lamport is a Lamport clock. It is created with NewLamport, and is safe for
+// concurrent use.
+type Lamport struct {
+	mu      sync.Mutex
+	clock   *crown.Clock
+	counter uint64
+}
+
+// NewLamport returns a Lamport clock stamping its timestamps with the time of
+// c, or not if c is nil.
+func NewLamport(c *crown.Clock) *Lamport {
+	return &Lamport{clock: c}
+}
+
+// Tick returns the timestamp of a local event, or of a message sent.
+func (l *Lamport) Tick() LamportStamp {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counter++
+	return LamportStamp{Counter: l.counter, Time: now(l.clock)}
+}
+
+// Receive updates the clock with the timestamp m of a message received, and
+// returns the timestamp of its reception.
+func (l *Lamport) Receive(m LamportStamp) LamportStamp {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if m.Counter > l.counter {
+		l.counter = m.Counter
+	}
+	l.counter++
+	return LamportStamp{Counter: l.counter, Time: now(l.clock)}
+}
+
+// Counter returns the counter of the clock, without ticking it.
+func (l *Lamport) Counter() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.counter
+}
+
+// Ordering is the causal order of two vectors.
+type Ordering int
+
+const (
+	// Equal means that the vectors are equal.
+	Equal Ordering = iota
+	// Before means that the first vector happened before the second.
+	Before
+	// After means that the first vector happened after the second.
+	After
+	// Concurrent means that neither vector happened before the other.
+	Concurrent
+)
+
+func (o Ordering) String() string {
+	switch o {
+	case Equal:
+		return "equal"
+	case Before:
+		return "before"
+	case After:
+		return "after"
+	case Concurrent:
+		return "concurrent"
+	}
+	return "Ordering(" + strconv.Itoa(int(o)) + ")"
+}
+
+// Vector is a vector of counters, by node. The counters of the nodes missing
+// from a Vector are 0.
+type Vector map[string]uint64
+
+// Clone returns a copy of v.
+func (v Vector) Clone() Vector {
+	clone := make(Vector, len(v))
+	for node, n := range v {
+		clone[node] = n
+	}
+	return clone
+}
+
+// Compare returns the causal order of v and u.
+func (v Vector) Compare(u Vector) Ordering {
+	less, greater := false, false
+	for node, n := range v {
+		switch {
+		case n < u[node]:
+			less = true
+		case n > u[node]:
+			greater = true
+		}
+	}
+	for node, n := range u {
+		if _, ok := v[node]; !ok && n > 0 {
+			less = true
+		}
+	}
+	switch {
+	case less && greater:
+		return Concurrent
+	case less:
+		return Before
+	case greater:
+		return After
+	}
+	return Equal
+}
+
+// String returns v with its nodes sorted, such as {a:1 b:3}.
+func (v Vector) String() string {
+	nodes := make([]string, 0, len(v))
+	for node := range v {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, node := range nodes {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(node + ":" + strconv.FormatUint(v[node], 10))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// VectorStamp is a timestamp of a vector clock.
+type VectorStamp struct {
+	Vector Vector
+	Time   time.Time // time of the crown clock, if any, at the event
+}
+
+// VectorClock is the vector clock of a node. It is created with
+// NewVectorClock, and is safe for concurrent use.
+type VectorClock struct {
+	mu     sync.Mutex
+	clock  *crown.Clock
+	node   string
+	vector Vector
+}
+
+// NewVectorClock returns the vector clock of the given node, stamping its
+// timestamps with the time of c, or not if c is nil.
+func NewVectorClock(node string, c *crown.Clock) *VectorClock {
+	return &VectorClock{clock: c, node: node, vector: make(Vector)}
+}
+
+// Tick returns the timestamp of a local event, or of a message sent.
+func (vc *VectorClock) Tick() VectorStamp {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	vc.vector[vc.node]++
+	return VectorStamp{Vector: vc.vector.Clone(), Time: now(vc.clock)}
+}
+
+// Receive updates the clock with the timestamp m of a message received, and
+// returns the timestamp of its reception.
+func (vc *VectorClock) Receive(m VectorStamp) VectorStamp {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	for node, n := range m.Vector {
+		if n > vc.vector[node] {
+			vc.vector[node] = n
+		}
+	}
+	vc.vector[vc.node]++
+	return VectorStamp{Vector: vc.vector.Clone(), Time: now(vc.clock)}
+}
+
+// Vector returns a copy of the vector of the clock, without ticking it.
+func (vc *VectorClock) Vector() Vector {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	return vc.vector.Clone()
+}
+
+// now returns the time of c, or the zero time if c is nil.
+func now(c *crown.Clock) time.Time {
+	if c == nil {
+		return time.Time{}
+	}
+	return c.Now()
+}
@@ -0,0 +1,64 @@
+package logical
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestLamport(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-18T09:00:00Z")
+	clock := crown.NewClock(refT)
+	a, b := NewLamport(clock), NewLamport(nil)
+	a.Tick()
+	m := a.Tick()
+	if m.Counter != 2 || !m.Time.Equal(refT) {
+		t.Errorf("Should stamp 2 at %s, got %+v", refT, m)
+	}
+	b.Tick()
+	if r := b.Receive(m); r.Counter != 3 || !r.Time.IsZero() {
+		t.Errorf("Reception should be 3, without time, got %+v", r)
+	}
+	clock.Forward(time.Second)
+	if r := a.Receive(LamportStamp{Counter: 1}); r.Counter != 3 || !r.Time.Equal(refT.Add(time.Second)) {
+		t.Errorf("Reception of an older message should be 3 at %s, got %+v", refT.Add(time.Second), r)
+	}
+	if n := b.Counter(); n != 3 {
+		t.Errorf("Counter should be 3, got %d", n)
+	}
+}
+
+func TestVectorClock(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-18T09:00:00Z")
+	clock := crown.NewClock(refT)
+	a, b, c := NewVectorClock("a", clock), NewVectorClock("b", clock), NewVectorClock("c", nil)
+	sent := a.Tick()
+	clock.Forward(time.Second)
+	received := b.Receive(sent)
+	if got := received.Vector.String(); got != "{a:1 b:1}" || !received.Time.Equal(refT.Add(time.Second)) {
+		t.Errorf("Reception should be {a:1 b:1} at %s, got %s at %s", refT.Add(time.Second), got, received.Time)
+	}
+	local := c.Tick()
+
+	for _, test := range []struct {
+		v, u Vector
+		want Ordering
+	}{
+		{sent.Vector, received.Vector, Before},
+		{received.Vector, sent.Vector, After},
+		{received.Vector, local.Vector, Concurrent},
+		{sent.Vector, a.Vector(), Equal},
+		{Vector{"a": 0}, Vector{}, Equal},
+	} {
+		if got := test.v.Compare(test.u); got != test.want {
+			t.Errorf("%s compared to %s should be %s, got %s", test.v, test.u, test.want, got)
+		}
+	}
+
+	// Timestamps do not share the vector of their clock.
+	sent.Vector["a"] = 10
+	if got := a.Vector().String(); got != "{a:1}" {
+		t.Errorf("Vector should be {a:1}, got %s", got)
+	}
+}
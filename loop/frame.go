@@ -0,0 +1,113 @@
+package loop
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Frame is a frame delivered by a FrameTicker.
+type Frame struct {
+	// Index is the number of the frame, counted in periods since the start
+	// of the ticker, from 1.
+	Index int64
+	// Time is the time of the clock at which the frame was scheduled.
+	Time time.Time
+	// Skipped is the number of frames overtaken by the clock advance which
+	// delivered this one.
+	Skipped int
+}
+
+// FrameStats counts the frames of a FrameTicker.
+type FrameStats struct {
+	// Frames is the number of frames delivered.
+	Frames int64
+	// Skipped is the number of frames overtaken by clock advances spanning
+	// several periods.
+	Skipped int64
+	// Dropped is the number of frames not delivered because the previous one
+	// had not been received yet.
+	Dropped int64
+}
+
+// FrameTicker emits frames at a fixed rate of the time of a clock, and counts
+// those which are skipped or dropped, to test rendering or streaming pacing.
+// It is created with NewFrameTicker.
+type FrameTicker struct {
+	C <-chan Frame
+
+	ticker *crown.Ticker
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	mu    sync.Mutex
+	stats FrameStats
+}
+
+// NewFrameTicker returns a ticker emitting fps frames per second of the clock
+// c. When an advance spans several frames, only the last one is delivered, and
+// the others are counted as skipped. The rate fps must be greater than zero;
+// if not, NewFrameTicker panics.
+func NewFrameTicker(c *crown.Clock, fps int) *FrameTicker {
+	if fps <= 0 {
+		panic("loop: non-positive frame rate")
+	}
+	period := time.Second / time.Duration(fps)
+	ch := make(chan Frame, 1)
+	f := &FrameTicker{
+		C:      ch,
+		ticker: c.NewTickerWithPolicy(period, crown.Skip),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go f.run(ch, c.Now(), period)
+	return f
+}
+
+func (f *FrameTicker) run(ch chan<- Frame, start time.Time, period time.Duration) {
+	defer close(f.done)
+	var last int64
+	for {
+		var tick time.Time
+		var ok bool
+		select {
+		case tick, ok = <-f.ticker.C:
+			if !ok {
+				return
+			}
+		case <-f.stop:
+			return
+		}
+		frame := Frame{Index: int64(tick.Sub(start) / period), Time: tick}
+		frame.Skipped = int(frame.Index - last - 1)
+		last = frame.Index
+		f.mu.Lock()
+		f.stats.Skipped += int64(frame.Skipped)
+		select {
+		case ch <- frame:
+			f.stats.Frames++
+		default:
+			f.stats.Dropped++
+		}
+		f.mu.Unlock()
+	}
+}
+
+// Stats returns the counts of the frames emitted so far.
+func (f *FrameTicker) Stats() FrameStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stats
+}
+
+// Stop turns off the ticker. No more frames are sent after Stop returns, but C
+// is not closed. Calling Stop again has no effect.
+func (f *FrameTicker) Stop() {
+	f.once.Do(func() {
+		f.ticker.Stop()
+		close(f.stop)
+	})
+	<-f.done
+}
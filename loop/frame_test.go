@@ -0,0 +1,55 @@
+package loop
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestFrameTicker(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-29T09:00:00Z")
+	clock := crown.NewClock(refT)
+	f := NewFrameTicker(clock, 50)
+	defer f.Stop()
+
+	clock.Forward(20 * time.Millisecond)
+	if frame := <-f.C; frame.Index != 1 || frame.Skipped != 0 || !frame.Time.Equal(refT.Add(20*time.Millisecond)) {
+		t.Errorf("Unexpected first frame %+v", frame)
+	}
+	clock.Forward(70 * time.Millisecond)
+	if frame := <-f.C; frame.Index != 4 || frame.Skipped != 2 {
+		t.Errorf("Should deliver frame 4 after skipping 2, got %+v", frame)
+	}
+
+	// Frames which are not received in time are dropped.
+	clock.Forward(20 * time.Millisecond)
+	waitStats(t, f, func(s FrameStats) bool { return s.Frames == 3 })
+	clock.Forward(20 * time.Millisecond)
+	waitStats(t, f, func(s FrameStats) bool { return s.Dropped == 1 })
+	if frame := <-f.C; frame.Index != 5 {
+		t.Errorf("Should keep frame 5, got %+v", frame)
+	}
+	if got, want := f.Stats(), (FrameStats{Frames: 3, Skipped: 2, Dropped: 1}); got != want {
+		t.Errorf("Should count %+v, got %+v", want, got)
+	}
+
+	// Stop can be called again, by the deferred call.
+	f.Stop()
+	clock.Forward(20 * time.Millisecond)
+	select {
+	case frame := <-f.C:
+		t.Errorf("Should not deliver frames once stopped, got %+v", frame)
+	default:
+	}
+}
+
+func waitStats(t *testing.T, f *FrameTicker, cond func(FrameStats) bool) {
+	t.Helper()
+	for i := 0; !cond(f.Stats()); i++ {
+		if i == 1000 {
+			t.Fatalf("Unexpected stats %+v", f.Stats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
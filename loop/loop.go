@@ -0,0 +1,94 @@
+// Package loop steps game-style simulations at a fixed timestep of the time
+// of a crown clock, so that engines and physics code can be driven
+// deterministically by advancing the clock.
+package loop
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Loop calls an update function at a fixed timestep, using an accumulator:
+// each frame adds the time elapsed on the clock since the previous frame, then
+// consumes it by whole steps. It is created with New.
+type Loop struct {
+	mu      sync.Mutex
+	clock   *crown.Clock
+	step    time.Duration
+	update  func(dt time.Duration)
+	last    time.Time
+	acc     time.Duration
+	steps   int64
+	dropped time.Duration
+
+	// MaxSteps, if positive, is the number of updates a single frame can run
+	// to catch up. The time left beyond is dropped, so that the simulation
+	// slows down rather than spiraling when updates cannot keep up.
+	MaxSteps int
+}
+
+// New returns a loop calling update with the timestep step, counting time
+// from the current time of the clock c. The step must be greater than zero;
+// if not, New panics.
+func New(c *crown.Clock, step time.Duration, update func(dt time.Duration)) *Loop {
+	if step <= 0 {
+		panic("loop: non-positive timestep")
+	}
+	return &Loop{clock: c, step: step, update: update, last: c.Now()}
+}
+
+// Frame accumulates the time elapsed on the clock since the previous frame,
+// and runs as many updates as it holds whole steps, up to MaxSteps. It returns
+// the number of updates run, and how far the accumulator is into the next
+// step, between 0 and 1, to interpolate rendering between two states.
+func (l *Loop) Frame() (steps int, alpha float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.clock.Now()
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.acc += elapsed
+	}
+	l.last = now
+	for l.acc >= l.step {
+		if l.MaxSteps > 0 && steps == l.MaxSteps {
+			drop := l.acc - l.acc%l.step
+			l.dropped += drop
+			l.acc -= drop
+			break
+		}
+		l.update(l.step)
+		l.acc -= l.step
+		steps++
+	}
+	l.steps += int64(steps)
+	return steps, float64(l.acc) / float64(l.step)
+}
+
+// Steps returns the number of updates run so far.
+func (l *Loop) Steps() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.steps
+}
+
+// Dropped returns the time dropped so far because of MaxSteps.
+func (l *Loop) Dropped() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Run runs a frame every time the clock has moved by frame, until ctx is done
+// or the clock is closed, and returns the error which stopped it, like
+// Clock.SleepWithContext.
+func (l *Loop) Run(ctx context.Context, frame time.Duration) error {
+	for {
+		if err := l.clock.SleepWithContext(ctx, frame); err != nil {
+			return err
+		}
+		l.Frame()
+	}
+}
@@ -0,0 +1,71 @@
+package loop
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestFrame(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-29T09:00:00Z")
+	clock := crown.NewClock(refT)
+	var simulated time.Duration
+	l := New(clock, 10*time.Millisecond, func(dt time.Duration) {
+		simulated += dt
+	})
+
+	clock.Forward(25 * time.Millisecond)
+	steps, alpha := l.Frame()
+	if steps != 2 || alpha != 0.5 {
+		t.Errorf("Should run 2 steps with alpha 0.5, got %d and %v", steps, alpha)
+	}
+	clock.Forward(5 * time.Millisecond)
+	if steps, _ := l.Frame(); steps != 1 {
+		t.Errorf("Should catch up the accumulated half step, got %d steps", steps)
+	}
+	if simulated != 30*time.Millisecond {
+		t.Errorf("Should have simulated 30ms, got %v", simulated)
+	}
+
+	l.MaxSteps = 3
+	clock.Forward(time.Second + 5*time.Millisecond)
+	steps, alpha = l.Frame()
+	if steps != 3 || alpha != 0.5 {
+		t.Errorf("Should run at most 3 steps and keep the remainder, got %d and %v", steps, alpha)
+	}
+	if got, want := l.Dropped(), 970*time.Millisecond; got != want {
+		t.Errorf("Should drop %v, got %v", want, got)
+	}
+	if got := l.Steps(); got != 6 {
+		t.Errorf("Should have run 6 steps, got %d", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-29T09:00:00Z")
+	clock := crown.NewClock(refT, crown.WithWakeAck())
+	steps := make(chan time.Duration, 10)
+	l := New(clock, 10*time.Millisecond, func(dt time.Duration) {
+		steps <- dt
+	})
+	done := make(chan error)
+	go func() {
+		done <- l.Run(context.Background(), 20*time.Millisecond)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(20 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if dt := <-steps; dt != 10*time.Millisecond {
+			t.Errorf("Should update by 10ms, got %v", dt)
+		}
+	}
+	clock.Close()
+	if err := <-done; !errors.Is(err, crown.ErrClockClosed) {
+		t.Errorf("Should stop with ErrClockClosed, got %v", err)
+	}
+}
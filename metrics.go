@@ -0,0 +1,61 @@
+package crown
+
+import "sync/atomic"
+
+// MetricsSink receives the metrics of a clock, so that the fake-time behavior
+// of a simulation can be monitored like production metrics. Its methods are
+// called synchronously and possibly concurrently by the goroutines acting on
+// the clock, and must be safe for concurrent use. The metrics are named after
+// the Metric constants.
+type MetricsSink interface {
+	// AddCounter adds delta to the counter name.
+	AddCounter(name string, delta float64)
+	// SetGauge sets the gauge name to value.
+	SetGauge(name string, value float64)
+	// ObserveHistogram records value in the histogram name.
+	ObserveHistogram(name string, value float64)
+}
+
+// Names of the metrics reported to a MetricsSink. Durations are in seconds of
+// clock time.
+const (
+	MetricAdvances       = "crown_advances_total"         // counter of clock advances
+	MetricAdvanced       = "crown_advanced_seconds_total" // counter of the forward moves of the clock
+	MetricWaits          = "crown_waits_total"            // counter of registered waits
+	MetricFires          = "crown_fires_total"            // counter of waits reaching their deadline
+	MetricCancellations  = "crown_cancellations_total"    // counter of interrupted waits
+	MetricWaiters        = "crown_waiters"                // gauge of pending waits
+	MetricAdvanceSeconds = "crown_advance_seconds"        // histogram of advance sizes
+	MetricWaitSeconds    = "crown_wait_duration_seconds"  // histogram of the durations of ended waits
+)
+
+// WithMetrics makes the clock report its metrics to sink.
+func WithMetrics(sink MetricsSink) Option {
+	return func(c *Clock) {
+		// The waiters are counted from the events, since a wait is
+		// reported registered before the clock counts it.
+		var waiters int64
+		c.addObserver(func(e Event) {
+			switch e.Kind {
+			case EventAdvance:
+				sink.AddCounter(MetricAdvances, 1)
+				if e.Advance > 0 {
+					sink.AddCounter(MetricAdvanced, e.Advance.Seconds())
+				}
+				sink.ObserveHistogram(MetricAdvanceSeconds, e.Advance.Seconds())
+				return
+			case EventRegister:
+				sink.AddCounter(MetricWaits, 1)
+				sink.SetGauge(MetricWaiters, float64(atomic.AddInt64(&waiters, 1)))
+			case EventFire:
+				sink.AddCounter(MetricFires, 1)
+				sink.ObserveHistogram(MetricWaitSeconds, e.Waited().Seconds())
+				sink.SetGauge(MetricWaiters, float64(atomic.AddInt64(&waiters, -1)))
+			case EventCancel:
+				sink.AddCounter(MetricCancellations, 1)
+				sink.ObserveHistogram(MetricWaitSeconds, e.Waited().Seconds())
+				sink.SetGauge(MetricWaiters, float64(atomic.AddInt64(&waiters, -1)))
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package crown
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// The model checker drives a clock and a reference model with the same
+// sequence of timer operations, and compares them after every step. Timers
+// follow the contract of WithStdChannels, which fully determines what can be
+// received from them.
+
+type modelOp int
+
+const (
+	opNewTimer modelOp = iota
+	opStop
+	opReset
+	opReceive
+	opForward
+	numModelOps
+)
+
+const maxModelTimers = 8
+
+// modelTimer is the reference state of a timer.
+type modelTimer struct {
+	deadline time.Time
+	state    TimerState
+	value    *time.Time // value pending on the channel, if any
+}
+
+type model struct {
+	now    time.Time
+	timers []*modelTimer
+}
+
+func (m *model) newTimer(d time.Duration) *modelTimer {
+	mt := &modelTimer{}
+	m.start(mt, d)
+	return mt
+}
+
+func (m *model) start(mt *modelTimer, d time.Duration) {
+	mt.deadline = m.now.Add(d)
+	mt.state = TimerPending
+	mt.value = nil
+	if d <= 0 {
+		m.fire(mt)
+	}
+}
+
+func (m *model) fire(mt *modelTimer) {
+	now := m.now
+	mt.state = TimerFired
+	mt.value = &now
+}
+
+func (m *model) stop(mt *modelTimer) bool {
+	if mt.state == TimerPending || mt.value != nil {
+		mt.state = TimerStopped
+		mt.value = nil
+		return true
+	}
+	return false
+}
+
+func (m *model) forward(d time.Duration) {
+	m.now = m.now.Add(d)
+	for _, mt := range m.timers {
+		if mt.state == TimerPending && !m.now.Before(mt.deadline) {
+			m.fire(mt)
+		}
+	}
+}
+
+func (m *model) waiters() int {
+	n := 0
+	for _, mt := range m.timers {
+		if mt.state == TimerPending {
+			n++
+		}
+	}
+	return n
+}
+
+// checkModel runs the operations encoded in data, two bytes each, against
+// both a clock and the model.
+func checkModel(t *testing.T, data []byte) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-12T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck(), WithStdChannels())
+	defer clock.Close()
+	m := &model{now: refT}
+	var timers []*Timer
+
+	for step := 0; step+1 < len(data); step += 2 {
+		op, arg := modelOp(data[step])%numModelOps, int(data[step+1])
+		d := time.Duration(arg%8) * time.Second
+		if len(timers) == 0 && op != opForward {
+			op = opNewTimer
+		}
+		i := 0
+		if len(timers) > 0 {
+			i = arg % len(timers)
+		}
+		desc := fmt.Sprintf("step %d", step/2)
+
+		switch op {
+		case opNewTimer:
+			if len(timers) == maxModelTimers {
+				continue
+			}
+			desc += fmt.Sprintf(": NewTimer(%v)", d)
+			timers = append(timers, clock.NewTimer(d))
+			m.timers = append(m.timers, m.newTimer(d))
+		case opStop:
+			desc += fmt.Sprintf(": timer %d Stop()", i)
+			if got, want := timers[i].Stop(), m.stop(m.timers[i]); got != want {
+				t.Fatalf("%s: Should return %v, got %v instead", desc, want, got)
+			}
+		case opReset:
+			desc += fmt.Sprintf(": timer %d Reset(%v)", i, d)
+			got := timers[i].Reset(d)
+			want := m.stop(m.timers[i])
+			m.start(m.timers[i], d)
+			if got != want {
+				t.Fatalf("%s: Should return %v, got %v instead", desc, want, got)
+			}
+		case opReceive:
+			desc += fmt.Sprintf(": timer %d receive", i)
+			mt := m.timers[i]
+			if mt.value == nil {
+				select {
+				case v := <-timers[i].C:
+					t.Fatalf("%s: Unexpected value %q", desc, v)
+				default:
+				}
+				break
+			}
+			select {
+			case v := <-timers[i].C:
+				if !v.Equal(*mt.value) {
+					t.Fatalf("%s: Should receive %q, got %q instead", desc, *mt.value, v)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: Should receive %q, got nothing", desc, *mt.value)
+			}
+			mt.value = nil
+		case opForward:
+			desc += fmt.Sprintf(": Forward(%v)", d)
+			clock.Forward(d)
+			m.forward(d)
+		}
+		settleModel(t, desc, clock, timers, m)
+	}
+}
+
+// settleModel waits for the clock to reach the state of the model, and fails
+// if it does not in time. Goroutine timers deregister their waits
+// asynchronously when stopped.
+func settleModel(t *testing.T, desc string, clock *Clock, timers []*Timer, m *model) {
+	t.Helper()
+	mismatch := func() string {
+		if got, want := clock.Now(), m.now; !got.Equal(want) {
+			return fmt.Sprintf("clock at %q, model at %q", got, want)
+		}
+		for i, timer := range timers {
+			if got, want := timer.State(), m.timers[i].state; got != want {
+				return fmt.Sprintf("timer %d %v, model %v", i, got, want)
+			}
+		}
+		if got, want := clock.Waiters(), m.waiters(); got != want {
+			return fmt.Sprintf("%d waiters, model %d", got, want)
+		}
+		return ""
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		diff := mismatch()
+		if diff == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: %s", desc, diff)
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+func FuzzModel(f *testing.F) {
+	f.Add([]byte{0, 3, 4, 2, 3, 0, 4, 1})
+	f.Add([]byte{0, 0, 3, 0, 2, 5, 1, 0, 3, 0})
+	f.Add([]byte{0, 2, 0, 4, 4, 3, 2, 1, 4, 7, 3, 0, 3, 1})
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		data := make([]byte, 2*(10+rng.Intn(30)))
+		rng.Read(data)
+		f.Add(data)
+	}
+	f.Fuzz(checkModel)
+}
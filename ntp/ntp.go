@@ -0,0 +1,180 @@
+// Package ntp simulates the synchronization of a local clock with a reference
+// clock by an NTP client: the local clock drifts from the reference, and the
+// client periodically measures its offset over the network and corrects it,
+// stepping the local clock when the offset is large and slewing it otherwise.
+// Both clocks are crown clocks, so that the code sensitive to the
+// synchronization of time can be tested deterministically.
+package ntp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Default values of the fields of Config.
+const (
+	DefaultStepThreshold = 128 * time.Millisecond
+	DefaultSlewRate      = 500e-6
+)
+
+// Config describes the local clock and its synchronization.
+type Config struct {
+	// Poll is the interval between two synchronizations, in reference
+	// time. It must be positive.
+	Poll time.Duration
+
+	// Delay is the network delay from the client to the server, and
+	// ReturnDelay the delay back, Delay if zero. NTP assumes both delays
+	// are equal: the offsets it measures are wrong by half their
+	// difference.
+	Delay, ReturnDelay time.Duration
+
+	// Offset is the initial offset of the local clock from the reference
+	// clock, and Drift how much the local clock gains per second of the
+	// reference clock, such as 20e-6 for 20 ppm.
+	Offset time.Duration
+	Drift  float64
+
+	// StepThreshold is the offset beyond which the local clock is stepped,
+	// DefaultStepThreshold if zero. SlewRate is the fraction of the elapsed
+	// time by which smaller offsets are corrected, DefaultSlewRate if zero.
+	StepThreshold time.Duration
+	SlewRate      float64
+}
+
+// Client synchronizes a local clock with a reference clock. It is created
+// with New, and is safe for concurrent use.
+type Client struct {
+	moveMu sync.Mutex // serializes the moves of the local clock
+	mu     sync.Mutex
+	ref    *crown.Clock
+	local  *crown.Clock
+	cfg    Config
+	last   time.Time     // reference time the offset is computed at
+	next   time.Time     // reference time of the next synchronization
+	offset time.Duration // of the local clock from the reference clock
+	slew   time.Duration // correction left to slew
+	polls  int
+	steps  int
+	remove func()
+}
+
+// New returns a client synchronizing a new local clock, created with opts,
+// with the clock ref according to cfg. The local clock moves whenever ref
+// moves, and is expected to be moved through ref only. The first
+// synchronization happens once ref has moved by cfg.Poll. New panics if
+// cfg.Poll is not positive.
+func New(ref *crown.Clock, cfg Config, opts ...crown.Option) *Client {
+	if cfg.Poll <= 0 {
+		panic("ntp: non-positive poll interval")
+	}
+	if cfg.ReturnDelay == 0 {
+		cfg.ReturnDelay = cfg.Delay
+	}
+	if cfg.StepThreshold == 0 {
+		cfg.StepThreshold = DefaultStepThreshold
+	}
+	if cfg.SlewRate == 0 {
+		cfg.SlewRate = DefaultSlewRate
+	}
+	now := ref.Now()
+	c := &Client{ref: ref, cfg: cfg, last: now, next: now.Add(cfg.Poll), offset: cfg.Offset}
+	c.local = crown.NewClock(now.Add(cfg.Offset), opts...)
+	c.remove = ref.OnAdvance(func(_, now time.Time) {
+		c.follow(now)
+	})
+	return c
+}
+
+// Clock returns the local clock.
+func (c *Client) Clock() *crown.Clock {
+	return c.local
+}
+
+// Offset returns the current offset of the local clock from the reference
+// clock.
+func (c *Client) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// Polls returns the number of synchronizations so far, and Steps how many
+// stepped the local clock.
+func (c *Client) Polls() (polls, steps int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.polls, c.steps
+}
+
+// Close stops the synchronization, and closes the local clock.
+func (c *Client) Close() error {
+	c.remove()
+	return c.local.Close()
+}
+
+// follow moves the local clock along with the reference clock, now at now,
+// synchronizing it at the polls on the way. The local clock is moved without
+// holding mu, so that the goroutines it releases can use the client.
+func (c *Client) follow(now time.Time) {
+	c.moveMu.Lock()
+	defer c.moveMu.Unlock()
+	for _, t := range c.moves(now) {
+		c.local.Set(t)
+	}
+}
+
+// moves accounts for the move of the reference clock to now, and returns the
+// successive times of the local clock: at each poll on the way, then at now.
+func (c *Client) moves(now time.Time) []time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Before(c.last) {
+		// The reference clock moved back: so does the local clock, its
+		// offset unchanged.
+		c.last = now
+		c.next = now.Add(c.cfg.Poll)
+		return []time.Time{now.Add(c.offset)}
+	}
+	var moves []time.Time
+	for !c.next.After(now) {
+		c.elapse(c.next)
+		c.sync()
+		c.next = c.next.Add(c.cfg.Poll)
+		moves = append(moves, c.last.Add(c.offset))
+	}
+	c.elapse(now)
+	return append(moves, now.Add(c.offset))
+}
+
+// elapse accounts for the drift and the slew of the local clock until the
+// reference time t.
+func (c *Client) elapse(t time.Time) {
+	dt := t.Sub(c.last)
+	c.last = t
+	c.offset += time.Duration(float64(dt) * c.cfg.Drift)
+	max := time.Duration(float64(dt) * c.cfg.SlewRate)
+	correction := c.slew
+	if correction > max {
+		correction = max
+	} else if correction < -max {
+		correction = -max
+	}
+	c.offset += correction
+	c.slew -= correction
+}
+
+// sync measures the offset of the local clock like NTP does, and corrects it.
+func (c *Client) sync() {
+	c.polls++
+	measured := c.offset + (c.cfg.ReturnDelay-c.cfg.Delay)/2
+	if measured > c.cfg.StepThreshold || measured < -c.cfg.StepThreshold {
+		c.offset -= measured
+		c.slew = 0
+		c.steps++
+		return
+	}
+	c.slew = -measured
+}
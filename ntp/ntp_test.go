@@ -0,0 +1,72 @@
+package ntp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// near reports whether d is within a microsecond of want.
+func near(d, want time.Duration) bool {
+	return d-want < time.Microsecond && want-d < time.Microsecond
+}
+
+func TestSlew(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-20T09:00:00Z")
+	ref := crown.NewClock(refT)
+	c := New(ref, Config{Poll: 64 * time.Second, Offset: 50 * time.Millisecond, Drift: 100e-6})
+	defer c.Close()
+
+	timer := c.Clock().NewTimer(time.Minute)
+	// The local clock gains 6ms per minute of the reference clock.
+	ref.Forward(time.Minute - 7*time.Millisecond)
+	select {
+	case <-timer.C:
+		t.Errorf("Timer of the local clock should not fire yet")
+	default:
+	}
+	ref.Forward(2 * time.Millisecond)
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer of the local clock should fire once it drifted by 6ms")
+	}
+
+	// The first poll measures 56.4ms, which is slewed by 32ms until the
+	// second poll, while the clock drifts by 6.4ms.
+	ref.Set(refT.Add(128 * time.Second))
+	if got := c.Offset(); !near(got, 30800*time.Microsecond) {
+		t.Errorf("Offset should be 30.8ms, got %s", got)
+	}
+	if got := c.Clock().Now().Sub(ref.Now()); got != c.Offset() {
+		t.Errorf("Local clock should be %s ahead, got %s", c.Offset(), got)
+	}
+	if polls, steps := c.Polls(); polls != 2 || steps != 0 {
+		t.Errorf("Should poll twice without stepping, got %d polls and %d steps", polls, steps)
+	}
+
+	// The reference clock moving back takes the local clock along.
+	ref.Set(refT)
+	if got := c.Clock().Now(); !got.Equal(refT.Add(c.Offset())) {
+		t.Errorf("Local clock should move back to %s, got %s", refT.Add(c.Offset()), got)
+	}
+}
+
+func TestStep(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-20T09:00:00Z")
+	ref := crown.NewClock(refT)
+	c := New(ref, Config{Poll: time.Minute, Offset: -time.Second, Delay: 10 * time.Millisecond, ReturnDelay: 30 * time.Millisecond})
+	defer c.Close()
+	ref.Forward(time.Minute)
+	if polls, steps := c.Polls(); polls != 1 || steps != 1 {
+		t.Errorf("Should step the clock at the first poll, got %d polls and %d steps", polls, steps)
+	}
+	// The asymmetry of the delays leaves an error of half their difference.
+	if got := c.Offset(); got != -10*time.Millisecond {
+		t.Errorf("Offset should be -10ms, got %s", got)
+	}
+	if got := c.Clock().Since(refT); got != time.Minute-10*time.Millisecond {
+		t.Errorf("Local clock should be stepped to 10ms behind, got %s", got)
+	}
+}
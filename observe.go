@@ -0,0 +1,245 @@
+package crown
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EventKind identifies what happened on a clock.
+type EventKind int
+
+const (
+	// EventAdvance means that the clock moved.
+	EventAdvance EventKind = iota
+	// EventRegister means that a wait was registered on the clock.
+	EventRegister
+	// EventFire means that a wait was released because the clock reached its
+	// deadline.
+	EventFire
+	// EventCancel means that a wait was interrupted before its deadline.
+	EventCancel
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventAdvance:
+		return "advance"
+	case EventRegister:
+		return "register"
+	case EventFire:
+		return "fire"
+	case EventCancel:
+		return "cancel"
+	}
+	return "EventKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *EventKind) UnmarshalText(text []byte) error {
+	for _, kind := range []EventKind{EventAdvance, EventRegister, EventFire, EventCancel} {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("crown: unknown event kind %q", text)
+}
+
+// Event describes something that happened on a clock.
+type Event struct {
+	Kind EventKind
+	// Time is the time of the clock when the event occurred.
+	Time time.Time
+	// Advance is how much the clock moved, for EventAdvance.
+	Advance time.Duration
+	// ID identifies the wait among those of the clock, for the other kinds.
+	// IDs are given from 1 in registration order, and are unrelated to
+	// GetSleepCount.
+	ID int64
+	// Wait tells what waits: "sleep", "timer", "ticker" or "context".
+	Wait string
+	// Trace is the trace identifier recorded along with the wait, see
+	// WithTraceContext.
+	Trace string
+	// Goroutine is the ID of the goroutine which registered the wait, or 0
+	// unless the clock was created with WithStackCapture.
+	Goroutine uint64
+	// Start and Deadline are the times of the clock when the wait was
+	// registered and when it is due.
+	Start    time.Time
+	Deadline time.Time
+	// Err is why the wait was interrupted, for EventCancel.
+	Err error
+}
+
+// eventJSON is the JSON encoding of an Event.
+type eventJSON struct {
+	Kind      EventKind     `json:"kind"`
+	Time      time.Time     `json:"time"`
+	Advance   time.Duration `json:"advance,omitempty"`
+	ID        int64         `json:"id,omitempty"`
+	Wait      string        `json:"wait,omitempty"`
+	Trace     string        `json:"trace,omitempty"`
+	Goroutine uint64        `json:"goroutine,omitempty"`
+	Start     *time.Time    `json:"start,omitempty"`
+	Deadline  *time.Time    `json:"deadline,omitempty"`
+	Err       string        `json:"err,omitempty"`
+}
+
+// knownErrors are the causes of interruption decoded as themselves by
+// Event.UnmarshalJSON, so that errors.Is keeps working on decoded events.
+var knownErrors = []error{ErrClockClosed, ErrCanceled, context.Canceled, context.DeadlineExceeded}
+
+// MarshalJSON implements json.Marshaler. The kind is encoded by name, the
+// advance in nanoseconds and the error by its message; the fields which do
+// not apply to the kind of the event are omitted.
+func (e Event) MarshalJSON() ([]byte, error) {
+	v := eventJSON{Kind: e.Kind, Time: e.Time, Advance: e.Advance, ID: e.ID, Wait: e.Wait, Trace: e.Trace, Goroutine: e.Goroutine}
+	if !e.Start.IsZero() {
+		v.Start = &e.Start
+	}
+	if !e.Deadline.IsZero() {
+		v.Deadline = &e.Deadline
+	}
+	if e.Err != nil {
+		v.Err = e.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The errors of the package and of
+// the context package are decoded as themselves, others as plain errors with
+// the same message.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var v eventJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = Event{Kind: v.Kind, Time: v.Time, Advance: v.Advance, ID: v.ID, Wait: v.Wait, Trace: v.Trace, Goroutine: v.Goroutine}
+	if v.Start != nil {
+		e.Start = *v.Start
+	}
+	if v.Deadline != nil {
+		e.Deadline = *v.Deadline
+	}
+	if v.Err != "" {
+		e.Err = errors.New(v.Err)
+		for _, known := range knownErrors {
+			if known.Error() == v.Err {
+				e.Err = known
+			}
+		}
+	}
+	return nil
+}
+
+// Waited returns how long the wait lasted in clock time, for EventFire and
+// EventCancel.
+func (e Event) Waited() time.Duration {
+	return e.Time.Sub(e.Start)
+}
+
+// WithObserver makes the clock call fn for every event occurring on it. The
+// function fn is called synchronously by the goroutine causing the event,
+// possibly concurrently with other calls: it must be safe for concurrent use,
+// return quickly and not advance the clock. Several observers can be set.
+func WithObserver(fn func(Event)) Option {
+	return func(c *Clock) {
+		c.addObserver(fn)
+	}
+}
+
+// OnAdvance makes the clock call fn with its former and new times every time
+// it moves, until the returned function is called. Like the observers of
+// WithObserver, fn is called synchronously by the goroutine moving the clock,
+// before the waits due are released, and must not advance the clock.
+func (c *Clock) OnAdvance(fn func(old, new time.Time)) (remove func()) {
+	return c.addObserver(func(e Event) {
+		if e.Kind == EventAdvance {
+			fn(e.Time.Add(-e.Advance), e.Time)
+		}
+	})
+}
+
+// OnWake makes the clock call fn for every wait released because the clock
+// reached its deadline, with the EventFire event describing the wait, until
+// the returned function is called. Like the observers of WithObserver, fn is
+// called synchronously by the goroutine moving the clock, right before the
+// wait is released, and must not advance the clock.
+func (c *Clock) OnWake(fn func(e Event)) (remove func()) {
+	return c.addObserver(func(e Event) {
+		if e.Kind == EventFire {
+			fn(e)
+		}
+	})
+}
+
+// observer wraps an observer function, so that it can be removed.
+type observer struct {
+	fn func(Event)
+}
+
+// addObserver adds fn to the observers of the clock, and returns a function
+// removing it.
+func (c *Clock) addObserver(fn func(Event)) (remove func()) {
+	o := &observer{fn: fn}
+	c.observersMu.Lock()
+	defer c.observersMu.Unlock()
+	observers := c.loadObservers()
+	c.observers.Store(append(observers[:len(observers):len(observers)], o))
+	return func() {
+		c.observersMu.Lock()
+		defer c.observersMu.Unlock()
+		var kept []*observer
+		for _, other := range c.loadObservers() {
+			if other != o {
+				kept = append(kept, other)
+			}
+		}
+		c.observers.Store(kept)
+	}
+}
+
+func (c *Clock) loadObservers() []*observer {
+	observers, _ := c.observers.Load().([]*observer)
+	return observers
+}
+
+// observed reports whether the clock has observers, so that events are only
+// built when needed.
+func (c *Clock) observed() bool {
+	return len(c.loadObservers()) > 0
+}
+
+func (c *Clock) emit(e Event) {
+	for _, o := range c.loadObservers() {
+		o.fn(e)
+	}
+}
+
+// emitWait emits an event of kind k about the wait of handler.
+func (c *Clock) emitWait(k EventKind, handler *sleepHandler, err error) {
+	if !c.observed() {
+		return
+	}
+	c.emit(Event{
+		Kind:      k,
+		Time:      c.Now(),
+		ID:        int64(handler.id),
+		Wait:      handler.kind,
+		Trace:     handler.trace,
+		Goroutine: handler.goid,
+		Start:     handler.start,
+		Deadline:  handler.deadline,
+		Err:       err,
+	})
+}
@@ -0,0 +1,87 @@
+package crown
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestObserver(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-14T09:00:00Z")
+	var mu sync.Mutex
+	var events []Event
+	clock := NewClock(refT, WithWakeAck(), WithObserver(func(e Event) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+
+	timer := clock.NewTimer(2 * time.Second)
+	stopped := clock.NewTimer(time.Minute)
+	clock.Forward(3 * time.Second)
+	<-timer.C
+	stopped.Stop()
+	for clock.Waiters() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []Event{
+		{Kind: EventRegister, Time: refT, Start: refT, Deadline: refT.Add(2 * time.Second)},
+		{Kind: EventRegister, Time: refT, Start: refT, Deadline: refT.Add(time.Minute)},
+		{Kind: EventAdvance, Time: refT.Add(3 * time.Second), Advance: 3 * time.Second},
+		{Kind: EventFire, Time: refT.Add(3 * time.Second), Start: refT, Deadline: refT.Add(2 * time.Second)},
+		{Kind: EventCancel, Time: refT.Add(3 * time.Second), Start: refT, Deadline: refT.Add(time.Minute), Err: errors.New("")},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Should observe %d events, got %v", len(want), events)
+	}
+	for i, e := range events {
+		w := want[i]
+		if e.Kind != w.Kind || !e.Time.Equal(w.Time) || e.Advance != w.Advance ||
+			!e.Start.Equal(w.Start) || !e.Deadline.Equal(w.Deadline) || (e.Err == nil) != (w.Err == nil) {
+			t.Errorf("Event %d: should be %+v, got %+v instead", i, w, e)
+		}
+	}
+	if got := events[3].Waited(); got != 3*time.Second {
+		t.Errorf("Fire should have waited 3s, got %v", got)
+	}
+}
+
+func TestOnAdvanceOnWake(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-14T09:00:00Z")
+	clock := NewClock(refT)
+	var moves [][2]time.Time
+	var woken []string
+	removeAdvance := clock.OnAdvance(func(old, new time.Time) {
+		moves = append(moves, [2]time.Time{old, new})
+	})
+	removeWake := clock.OnWake(func(e Event) {
+		woken = append(woken, e.Wait)
+	})
+
+	timer := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	stopped.Stop()
+	clock.Forward(time.Minute)
+	<-timer.C
+	if len(moves) != 1 || !moves[0][0].Equal(refT) || !moves[0][1].Equal(refT.Add(time.Minute)) {
+		t.Errorf("Should report the move from %q by 1m, got %v", refT, moves)
+	}
+	if len(woken) != 1 || woken[0] != "timer" {
+		t.Errorf("Should report the fired timer only, got %v", woken)
+	}
+
+	removeAdvance()
+	removeWake()
+	clock.NewTimer(time.Second)
+	clock.Forward(time.Minute)
+	if len(moves) != 1 || len(woken) != 1 {
+		t.Errorf("Should not call removed hooks, got %v and %v", moves, woken)
+	}
+	if clock.observed() {
+		t.Errorf("Should have no observer left")
+	}
+}
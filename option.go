@@ -0,0 +1,91 @@
+package crown
+
+import "time"
+
+// Option configures a Clock created with NewClock.
+type Option func(*Clock)
+
+// WithWakeAck makes Forward wait until every sleeper it releases has actually
+// resumed before returning: released Sleep calls are returning, and fired
+// Timers have already sent the time on their channel. Tests can then assert on
+// timer channels right after Forward, without polling.
+func WithWakeAck() Option {
+	return func(c *Clock) {
+		c.wakeAck = true
+	}
+}
+
+// WithStepwiseForward makes the clock stop at each pending deadline when it
+// moves forward: Forward(30*time.Second) first moves the clock to the deadline
+// of a timer armed for 5 seconds and fires it, so that the woken code reads a
+// time consistent with its deadline, then goes on to the next deadline, and so
+// on. Each stop counts as an advance, in statistics and events. Combined with
+// WithWakeAck, the waits registered by the released goroutines before they
+// resume, such as the next ticks of tickers, are stepped through by the same
+// advance.
+func WithStepwiseForward() Option {
+	return func(c *Clock) {
+		c.stepwise = true
+	}
+}
+
+// WithStrictMonotonic makes the clock reject, with a panic, any operation that
+// would move its time backward, such as a negative Forward.
+func WithStrictMonotonic() Option {
+	return func(c *Clock) {
+		c.monotonic = true
+	}
+}
+
+// DeadlinePolicy defines what happens to a wait whose deadline is exactly the
+// current time of the clock when it starts, such as Sleep(0).
+type DeadlinePolicy int
+
+const (
+	// FireImmediately makes the wait return, or the timer fire, right away.
+	// This is the default policy.
+	FireImmediately DeadlinePolicy = iota
+	// WaitForAdvance makes the wait block until the next clock advance, even
+	// a Forward(0). Waits whose deadline is already past still return
+	// immediately.
+	WaitForAdvance
+)
+
+// WithDeadlinePolicy sets the policy applied to the waits whose deadline is
+// the current time of the clock.
+func WithDeadlinePolicy(p DeadlinePolicy) Option {
+	return func(c *Clock) {
+		c.policy = p
+	}
+}
+
+// WithLocation sets the location of the times of the clock: those returned by
+// Now, sent by timers and tickers, and reported in events, whatever the
+// location of the times the clock is started at or set to. Calendar-dependent
+// code under test then sees consistent zoned times.
+func WithLocation(loc *time.Location) Option {
+	return func(c *Clock) {
+		c.loc = loc
+	}
+}
+
+// WithStdChannels makes the channels of timers and tickers behave like those
+// of the time package since Go 1.23: they are never closed, even once the
+// timer has fired or been stopped, and Stop and Reset discard the values not
+// received yet. By default, they are closed as soon as nothing more will be
+// sent on them, which ends range loops over them.
+func WithStdChannels() Option {
+	return func(c *Clock) {
+		c.stdChannels = true
+	}
+}
+
+// WithStackCapture makes the clock record the call stack and the goroutine of
+// every Sleep, SleepWithContext, NewTimer and NewTicker call, so that the
+// waits still pending can be attributed to their origin by CheckLeaks and
+// Pending.
+func WithStackCapture() Option {
+	return func(c *Clock) {
+		c.stacks = true
+	}
+}
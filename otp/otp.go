@@ -0,0 +1,111 @@
+// Package otp computes HOTP (RFC 4226) and TOTP (RFC 6238) one-time passwords
+// with time steps taken from a crown clock, so that two-factor verification
+// windows and drift tolerance can be tested across step boundaries.
+//
+// Code relying on an OTP library can instead pass the time of the clock to
+// the validation functions taking an explicit time, such as
+// totp.ValidateCustom of github.com/pquerna/otp.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// HOTP returns the HMAC-SHA1 one-time password of secret for counter, with
+// the given number of digits, from 1 to 9: RFC 4226 recommends 6 to 8. HOTP
+// panics if digits is out of range.
+func HOTP(secret []byte, counter uint64, digits int) string {
+	if digits < 1 || digits > 9 {
+		panic("otp: number of digits not between 1 and 9")
+	}
+	mac := hmac.New(sha1.New, secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod)
+}
+
+// TOTP generates and verifies time-based one-time passwords timed by a clock.
+type TOTP struct {
+	Clock  *crown.Clock
+	Secret []byte
+	// Period is the duration of a time step, a whole number of seconds. It
+	// defaults to 30 seconds. The methods of TOTP panic if it is positive but
+	// not a whole number of seconds.
+	Period time.Duration
+	// Digits is the length of the passwords, from 1 to 9. It defaults to 6.
+	// The methods of TOTP panic if it is out of range.
+	Digits int
+	// Skew is the number of steps before and after the current one whose
+	// passwords are also accepted by Verify, to tolerate clock drift.
+	Skew int
+}
+
+// period returns the duration of a time step, in seconds.
+func (t *TOTP) period() int64 {
+	if t.Period <= 0 {
+		return 30
+	}
+	if t.Period%time.Second != 0 {
+		panic("otp: period not a whole number of seconds")
+	}
+	return int64(t.Period / time.Second)
+}
+
+func (t *TOTP) digits() int {
+	switch {
+	case t.Digits == 0:
+		return 6
+	case t.Digits < 1 || t.Digits > 9:
+		panic("otp: number of digits not between 1 and 9")
+	}
+	return t.Digits
+}
+
+// Step returns the current time step: the number of periods elapsed since
+// the Unix epoch at the time of the clock.
+func (t *TOTP) Step() uint64 {
+	return uint64(t.Clock.Now().Unix() / t.period())
+}
+
+// Remaining returns how long the current step lasts, in clock time.
+func (t *TOTP) Remaining() time.Duration {
+	now := t.Clock.Now()
+	next := time.Unix(int64(t.Step()+1)*t.period(), 0)
+	return next.Sub(now)
+}
+
+// Generate returns the password of the current step.
+func (t *TOTP) Generate() string {
+	return HOTP(t.Secret, t.Step(), t.digits())
+}
+
+// Verify reports whether code is the password of the current step, or of one
+// of the Skew steps around it, and if so the offset of its step relative to
+// the current one.
+func (t *TOTP) Verify(code string) (offset int, ok bool) {
+	step := int64(t.Step())
+	for d := -t.Skew; d <= t.Skew; d++ {
+		s := step + int64(d)
+		if s < 0 {
+			continue
+		}
+		want := HOTP(t.Secret, uint64(s), t.digits())
+		if subtle.ConstantTimeCompare([]byte(code), []byte(want)) == 1 {
+			return d, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,94 @@
+package otp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestRFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	for _, tt := range []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+	} {
+		totp := &TOTP{Clock: crown.NewClock(time.Unix(tt.unix, 0)), Secret: secret, Digits: 8}
+		if got := totp.Generate(); got != tt.want {
+			t.Errorf("At %d, should generate %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestVerifyAcrossSteps(t *testing.T) {
+	clock := crown.NewClock(time.Unix(1671872400, 0)) // start of a step
+	totp := &TOTP{Clock: clock, Secret: []byte("secret"), Skew: 1}
+	code := totp.Generate()
+	if got := totp.Remaining(); got != 30*time.Second {
+		t.Errorf("Step should last 30s, got %v", got)
+	}
+
+	clock.Forward(29 * time.Second)
+	if offset, ok := totp.Verify(code); !ok || offset != 0 {
+		t.Errorf("Code should be valid during its step, got %d %v", offset, ok)
+	}
+	clock.Forward(time.Second)
+	if offset, ok := totp.Verify(code); !ok || offset != -1 {
+		t.Errorf("Code should be tolerated one step later, got %d %v", offset, ok)
+	}
+	clock.Forward(30 * time.Second)
+	if _, ok := totp.Verify(code); ok {
+		t.Errorf("Code should be rejected two steps later")
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	clock := crown.NewClock(time.Unix(1671872400, 0))
+	totp := &TOTP{Clock: clock, Secret: []byte("secret"), Period: time.Minute}
+	if got := totp.Remaining(); got != time.Minute {
+		t.Errorf("Step should last 1m, got %v", got)
+	}
+	for _, period := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Period of %v should panic", period)
+				}
+			}()
+			(&TOTP{Clock: clock, Period: period}).Step()
+		}()
+	}
+}
+
+func TestDigits(t *testing.T) {
+	if got := HOTP([]byte("12345678901234567890"), 0, 9); got != "284755224" {
+		t.Errorf("Should give 9 digits, got %s", got)
+	}
+	clock := crown.NewClock(time.Unix(1671872400, 0))
+	for _, digits := range []int{-1, 0, 10} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("HOTP with %d digits should panic", digits)
+				}
+			}()
+			HOTP([]byte("secret"), 0, digits)
+		}()
+		if digits == 0 {
+			continue // the default of TOTP
+		}
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TOTP with %d digits should panic", digits)
+				}
+			}()
+			(&TOTP{Clock: clock, Secret: []byte("secret"), Digits: digits}).Generate()
+		}()
+	}
+}
@@ -0,0 +1,131 @@
+package crown
+
+import (
+	"container/heap"
+	"time"
+)
+
+// waitQueue stores the handlers registered on a clock. Its methods are
+// called with Clock.waitersMu held.
+type waitQueue interface {
+	// push adds handler, whose id is set, to the queue.
+	push(handler *sleepHandler)
+	// remove removes the handler stored under key, and returns it, if any.
+	remove(key uint64) (*sleepHandler, bool)
+	// popDue removes and returns the first handler due at now, by deadline
+	// then registration order, among those registered up to key last. It
+	// returns nil if there is none.
+	popDue(now time.Time, last uint64) *sleepHandler
+	// next returns the earliest deadline, and reports false if the queue is
+	// empty.
+	next() (time.Time, bool)
+	// deadlineAfter returns the earliest deadline after t, and reports false
+	// if there is none.
+	deadlineAfter(t time.Time) (time.Time, bool)
+	// handlers returns the handlers of the queue, in no particular order.
+	handlers() []*sleepHandler
+}
+
+// heapQueue is a min-heap of handlers, ordered by deadline, then by
+// registration order, indexed by key. It is the default queue of clocks, and
+// its zero value is an empty queue.
+type heapQueue struct {
+	heap waitHeap
+	byID map[uint64]*sleepHandler
+}
+
+func (q *heapQueue) push(handler *sleepHandler) {
+	if q.byID == nil {
+		q.byID = make(map[uint64]*sleepHandler)
+	}
+	heap.Push(&q.heap, handler)
+	q.byID[handler.id] = handler
+}
+
+func (q *heapQueue) remove(key uint64) (*sleepHandler, bool) {
+	handler, ok := q.byID[key]
+	if !ok {
+		return nil, false
+	}
+	delete(q.byID, key)
+	heap.Remove(&q.heap, handler.index)
+	return handler, true
+}
+
+func (q *heapQueue) popDue(now time.Time, last uint64) *sleepHandler {
+	if len(q.heap) == 0 {
+		return nil
+	}
+	first := q.heap[0]
+	if now.Before(first.deadline) || first.id > last {
+		return nil
+	}
+	heap.Pop(&q.heap)
+	delete(q.byID, first.id)
+	return first
+}
+
+func (q *heapQueue) next() (time.Time, bool) {
+	if len(q.heap) == 0 {
+		return time.Time{}, false
+	}
+	return q.heap[0].deadline, true
+}
+
+func (q *heapQueue) deadlineAfter(t time.Time) (time.Time, bool) {
+	return q.heap.deadlineAfter(t)
+}
+
+func (q *heapQueue) handlers() []*sleepHandler {
+	return append([]*sleepHandler(nil), q.heap...)
+}
+
+// waitHeap implements heap.Interface.
+type waitHeap []*sleepHandler
+
+// deadlineAfter returns the earliest deadline after t in the heap, and
+// reports false if there is none.
+func (h waitHeap) deadlineAfter(t time.Time) (time.Time, bool) {
+	if len(h) == 0 {
+		return time.Time{}, false
+	}
+	if h[0].deadline.After(t) {
+		return h[0].deadline, true
+	}
+	// Some deadlines are not after t, which is rare: scan the heap.
+	var next time.Time
+	found := false
+	for _, handler := range h {
+		if handler.deadline.After(t) && (!found || handler.deadline.Before(next)) {
+			next, found = handler.deadline, true
+		}
+	}
+	return next, found
+}
+
+func (h waitHeap) Len() int { return len(h) }
+
+func (h waitHeap) Less(i, j int) bool {
+	return h[i].before(h[j])
+}
+
+func (h waitHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waitHeap) Push(x any) {
+	handler := x.(*sleepHandler)
+	handler.index = len(*h)
+	*h = append(*h, handler)
+}
+
+func (h *waitHeap) Pop() any {
+	old := *h
+	n := len(old) - 1
+	handler := old[n]
+	old[n] = nil
+	*h = old[:n]
+	return handler
+}
@@ -0,0 +1,68 @@
+package crown
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithRandSeed seeds the random numbers of the clock, see Rand, with seed, so
+// that a whole simulated timeline, randomized delays included, can be
+// reproduced from it.
+func WithRandSeed(seed int64) Option {
+	return func(c *Clock) {
+		c.initRand(seed)
+	}
+}
+
+// Rand returns the source of random numbers of the clock, from which the
+// features of the clock drawing randomized delays, the sim package and the
+// code under test draw alike, so that they are reproducible from the seed of
+// the clock, see Seed. Its methods, except Read, are safe for concurrent use,
+// the numbers drawn concurrently being reproducible only if the order of the
+// draws is.
+func (c *Clock) Rand() *rand.Rand {
+	c.initRand(time.Now().UnixNano())
+	return c.rand
+}
+
+// Seed returns the seed of the random numbers of the clock: the one given to
+// WithRandSeed, or one drawn from the wall time, to be logged by tests so
+// that a failing run can be reproduced with WithRandSeed.
+func (c *Clock) Seed() int64 {
+	c.initRand(time.Now().UnixNano())
+	return c.seed
+}
+
+// initRand seeds the random numbers of the clock with seed, unless they are
+// already seeded.
+func (c *Clock) initRand(seed int64) {
+	c.randOnce.Do(func() {
+		c.seed = seed
+		c.rand = rand.New(&lockedSource{src: rand.NewSource(seed).(rand.Source64)})
+	})
+}
+
+// lockedSource is a rand.Source64 safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
@@ -0,0 +1,45 @@
+package crown
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRandSeed(t *testing.T) {
+	draw := func(c *Clock) []int64 {
+		var ns []int64
+		for i := 0; i < 5; i++ {
+			ns = append(ns, c.Rand().Int63())
+		}
+		return ns
+	}
+	c1 := NewClock(time.Time{}, WithRandSeed(42))
+	c2 := NewClock(time.Time{}, WithRandSeed(42))
+	if c1.Seed() != 42 {
+		t.Errorf("Seed should be 42, got %d", c1.Seed())
+	}
+	got, want := draw(c1), draw(c2)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Clocks with the same seed should draw the same numbers, got %v and %v", got, want)
+		}
+	}
+
+	// A zero clock draws from a seed it reports.
+	var c Clock
+	got = draw(&c)
+	if want := draw(NewClock(time.Time{}, WithRandSeed(c.Seed()))); got[0] != want[0] {
+		t.Errorf("Should reproduce the numbers from the reported seed, got %v and %v", got, want)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			draw(c1)
+		}()
+	}
+	wg.Wait()
+}
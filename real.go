@@ -0,0 +1,103 @@
+package crown
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Interface is implemented by both Clock and RealClock. Production code can
+// depend on it, use RealClock, and get a Clock injected by tests.
+type Interface interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Until(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) *Timer
+	NewTimer(d time.Duration) *Timer
+	NewTicker(d time.Duration) *Ticker
+}
+
+var (
+	_ Interface = (*Clock)(nil)
+	_ Interface = RealClock{}
+)
+
+// RealClock implements Interface with the time package. Its timers and
+// tickers are backed by those of the time package: their channels are never
+// closed, and the channel of a timer is not drained by Stop or Reset, like
+// with time.Timer before Go 1.23.
+type RealClock struct{}
+
+// Now is the equivalent of time.Now.
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Since is the equivalent of time.Since.
+func (RealClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Until is the equivalent of time.Until.
+func (RealClock) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+
+// Sleep is the equivalent of time.Sleep.
+func (RealClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// After is the equivalent of time.After.
+func (c RealClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C
+}
+
+// AfterFunc is the equivalent of time.AfterFunc.
+func (RealClock) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{fn: f}
+	t.startStd(d)
+	return t
+}
+
+// NewTimer is the equivalent of time.NewTimer.
+func (RealClock) NewTimer(d time.Duration) *Timer {
+	t := &Timer{}
+	t.startStd(d)
+	return t
+}
+
+// NewTicker is the equivalent of time.NewTicker.
+func (RealClock) NewTicker(d time.Duration) *Ticker {
+	std := time.NewTicker(d)
+	return &Ticker{C: std.C, std: std}
+}
+
+// startStd arms a timer of the time package, firing the timer after duration
+// d.
+func (t *Timer) startStd(d time.Duration) {
+	var ch chan time.Time
+	if t.fn == nil {
+		ch = make(chan time.Time, 1)
+		t.C = ch
+	}
+	t.std = time.AfterFunc(d, func() {
+		if !t.fired(nil) {
+			return
+		}
+		if t.fn != nil {
+			t.fn()
+			return
+		}
+		select {
+		case ch <- time.Now():
+		default:
+		}
+	})
+}
+
+// stopStd stops a timer of the time package, like stop.
+func (t *Timer) stopStd() bool {
+	return t.std.Stop() && atomic.CompareAndSwapInt32(&t.state, int32(TimerPending), int32(TimerStopped))
+}
@@ -0,0 +1,79 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+// elapse returns the time reported by c after sleeping for d on it.
+func elapse(c Interface, d time.Duration) time.Duration {
+	start := c.Now()
+	c.Sleep(d)
+	return c.Since(start)
+}
+
+func TestInterface(t *testing.T) {
+	if got := elapse(RealClock{}, time.Millisecond); got < time.Millisecond {
+		t.Errorf("Should sleep for at least 1ms, got %v", got)
+	}
+
+	clock := NewClock(time.Time{})
+	done := make(chan time.Duration)
+	go func() {
+		done <- elapse(clock, time.Hour)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(time.Hour)
+	if got := <-done; got != time.Hour {
+		t.Errorf("Should sleep for 1h, got %v", got)
+	}
+}
+
+func TestRealTimer(t *testing.T) {
+	var c RealClock
+	timer := c.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatalf("Did not fire after 1 sec")
+	}
+	if got := timer.State(); got != TimerFired {
+		t.Errorf("Should be %v, got %v instead", TimerFired, got)
+	}
+	if timer.Stop() {
+		t.Errorf("Stop() on a fired timer should return false")
+	}
+
+	if timer.Reset(time.Hour) {
+		t.Errorf("Reset() on a fired timer should return false")
+	}
+	if !timer.Stop() {
+		t.Errorf("Stop() on a pending timer should return true")
+	}
+	if got := timer.State(); got != TimerStopped {
+		t.Errorf("Should be %v, got %v instead", TimerStopped, got)
+	}
+
+	called := make(chan struct{})
+	c.AfterFunc(time.Millisecond, func() { close(called) })
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatalf("Function not called after 1 sec")
+	}
+}
+
+func TestRealTicker(t *testing.T) {
+	ticker := RealClock{}.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ticker.C:
+		case <-time.After(time.Second):
+			t.Fatalf("Did not tick after 1 sec")
+		}
+	}
+	ticker.Reset(time.Hour)
+}
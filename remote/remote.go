@@ -0,0 +1,297 @@
+// Package remote shares the time of a crown clock with other processes, so
+// that a test harness can govern the simulated time of the child processes it
+// spawns, and run multi-process integration tests on a single timeline.
+//
+// The harness listens for the clocks of its children, and attaches the server
+// to its own clock:
+//
+//	srv, err := remote.Listen("unix", filepath.Join(t.TempDir(), "clock"))
+//	...
+//	clock := crown.NewClock(start, srv.Option())
+//	go srv.Serve()
+//	defer srv.Close()
+//	cmd.Env = append(os.Environ(), srv.Env())
+//
+// Each child gets a local clock following the one of the harness:
+//
+//	client, err := remote.DialEnv()
+//	...
+//	clock := client.Clock
+//
+// Every advance of the harness clock is replicated to the connected clients,
+// and Forward only returns once each of them has applied it. With clients
+// created with crown.WithWakeAck, their released sleepers have then resumed.
+package remote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// EnvVar is the environment variable through which a harness passes the
+// address of its server to its children, in the form network:address.
+const EnvVar = "CROWN_CLOCK"
+
+// update is sent by the server with the time of its clock.
+type update struct {
+	Now time.Time `json:"now"`
+}
+
+// ack is sent by a client once it has applied an update.
+type ack struct {
+	Waiters int `json:"waiters"`
+}
+
+// Server replicates the time of a clock to the clients connected to it. It is
+// created with Listen and attached to its clock with Option.
+type Server struct {
+	listener net.Listener
+	clock    *crown.Clock
+
+	// Timeout bounds how long an advance waits for the acknowledgment of
+	// each client. A client which does not acknowledge in time is
+	// disconnected. It defaults to 10 seconds.
+	Timeout time.Duration
+
+	mu         sync.Mutex
+	conns      map[*serverConn]struct{}
+	connecting map[*serverConn]struct{} // clients being sent the time
+	advances   int                      // number of advances observed
+	closed     bool
+}
+
+type serverConn struct {
+	conn    net.Conn
+	enc     *json.Encoder
+	dec     *json.Decoder
+	waiters int
+}
+
+// Listen returns a server listening on the given network address, such as a
+// unix socket or a localhost TCP port, see net.Listen.
+func Listen(network, address string) (*Server, error) {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		listener:   l,
+		conns:      make(map[*serverConn]struct{}),
+		connecting: make(map[*serverConn]struct{}),
+	}, nil
+}
+
+// Option returns the option attaching the server to the clock it is passed
+// to. It must be passed to a single clock.
+func (s *Server) Option() crown.Option {
+	return func(c *crown.Clock) {
+		s.clock = c
+		crown.WithObserver(s.observe)(c)
+	}
+}
+
+// Env returns the environment entry giving the address of the server to the
+// children, for DialEnv.
+func (s *Server) Env() string {
+	addr := s.listener.Addr()
+	return EnvVar + "=" + addr.Network() + ":" + addr.String()
+}
+
+// Clients returns the number of clients currently connected.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Waiters returns the number of waits pending on the clocks of the clients,
+// as reported by their last acknowledgment.
+func (s *Server) Waiters() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for sc := range s.conns {
+		n += sc.waiters
+	}
+	return n
+}
+
+// Serve accepts clients until the server is closed. Each client is sent the
+// current time of the clock before being connected. Serve always returns a
+// non-nil error, net.ErrClosed after Close.
+func (s *Server) Serve() error {
+	if s.clock == nil {
+		return errors.New("remote: server not attached to a clock")
+	}
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.connect(&serverConn{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)})
+	}
+}
+
+// connect sends the current time of the clock to the client of sc, without
+// holding the lock so that advances are not held up by a slow client, and
+// then adds sc to the connected clients. It sends the time again if the clock
+// was advanced meanwhile, as the advance was not replicated to sc.
+func (s *Server) connect(sc *serverConn) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		sc.conn.Close()
+		return
+	}
+	s.connecting[sc] = struct{}{}
+	defer func() {
+		s.mu.Lock()
+		delete(s.connecting, sc)
+		s.mu.Unlock()
+	}()
+	for {
+		advances := s.advances
+		s.mu.Unlock()
+		// The clock is moved before its advance is observed: the time read
+		// now includes the advances counted so far.
+		if !s.sync(sc, s.clock.Now()) {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			sc.conn.Close()
+			return
+		}
+		if s.advances == advances {
+			s.conns[sc] = struct{}{}
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Close stops the server and disconnects its clients, whose clocks are then
+// closed.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for sc := range s.conns {
+		sc.conn.Close()
+		delete(s.conns, sc)
+	}
+	for sc := range s.connecting {
+		sc.conn.Close()
+	}
+	return s.listener.Close()
+}
+
+// observe replicates the advances of the clock to the clients.
+func (s *Server) observe(e crown.Event) {
+	if e.Kind != crown.EventAdvance {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advances++
+	for sc := range s.conns {
+		if !s.sync(sc, e.Time) {
+			delete(s.conns, sc)
+		}
+	}
+}
+
+// sync sends now to the client of sc and waits for its acknowledgment. It
+// closes the connection and reports false if that fails.
+func (s *Server) sync(sc *serverConn, now time.Time) bool {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	sc.conn.SetDeadline(time.Now().Add(timeout))
+	var a ack
+	if sc.enc.Encode(update{Now: now}) != nil || sc.dec.Decode(&a) != nil {
+		sc.conn.Close()
+		return false
+	}
+	sc.waiters = a.Waiters
+	return true
+}
+
+// Client is a local clock following the clock of a server.
+type Client struct {
+	// Clock follows the time of the server. It is closed when the
+	// connection to the server is lost.
+	Clock *crown.Clock
+
+	conn net.Conn
+	done chan struct{}
+}
+
+// Dial connects to the server at the given network address, and returns a
+// client whose clock, created with opts, starts at the current time of the
+// server clock.
+func Dial(network, address string, opts ...crown.Option) (*Client, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	enc, dec := json.NewEncoder(conn), json.NewDecoder(conn)
+	var u update
+	if err := dec.Decode(&u); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	cl := &Client{Clock: crown.NewClock(u.Now, opts...), conn: conn, done: make(chan struct{})}
+	if err := enc.Encode(ack{}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("remote: %w", err)
+	}
+	go cl.run(enc, dec)
+	return cl, nil
+}
+
+// DialEnv connects to the server whose address is given by the environment
+// variable EnvVar, like Dial.
+func DialEnv(opts ...crown.Option) (*Client, error) {
+	v := os.Getenv(EnvVar)
+	network, address, ok := strings.Cut(v, ":")
+	if !ok {
+		return nil, fmt.Errorf("remote: invalid %s %q", EnvVar, v)
+	}
+	return Dial(network, address, opts...)
+}
+
+// run applies the updates of the server to the clock.
+func (cl *Client) run(enc *json.Encoder, dec *json.Decoder) {
+	defer close(cl.done)
+	defer cl.Clock.Close()
+	for {
+		var u update
+		if dec.Decode(&u) != nil {
+			return
+		}
+		if d := u.Now.Sub(cl.Clock.Now()); d != 0 {
+			cl.Clock.Forward(d)
+		}
+		if enc.Encode(ack{Waiters: cl.Clock.Waiters()}) != nil {
+			return
+		}
+	}
+}
+
+// Close disconnects the client from the server and closes its clock.
+func (cl *Client) Close() error {
+	err := cl.conn.Close()
+	<-cl.done
+	return err
+}
@@ -0,0 +1,137 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestRemote(t *testing.T) {
+	srv, err := Listen("unix", filepath.Join(t.TempDir(), "clock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refT, _ := time.Parse(time.RFC3339, "2022-12-30T09:00:00Z")
+	clock := crown.NewClock(refT, srv.Option())
+	served := make(chan error)
+	go func() {
+		served <- srv.Serve()
+	}()
+
+	env := srv.Env()
+	if !strings.HasPrefix(env, EnvVar+"=unix:") {
+		t.Errorf("Unexpected environment entry %q", env)
+	}
+	t.Setenv(EnvVar, strings.TrimPrefix(env, EnvVar+"="))
+	client, err := DialEnv(crown.WithWakeAck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if got := client.Clock.Now(); !got.Equal(refT) {
+		t.Errorf("Client should start at %v, got %v", refT, got)
+	}
+
+	slept := make(chan error, 1)
+	go func() {
+		slept <- client.Clock.SleepWithContext(context.Background(), time.Minute)
+	}()
+	for client.Clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(time.Second)
+	if got := srv.Waiters(); got != 1 {
+		t.Errorf("Should report 1 remote waiter, got %d", got)
+	}
+	clock.Forward(time.Minute)
+	// Forward returns once the client has applied the advance.
+	if got, want := client.Clock.Now(), refT.Add(time.Minute+time.Second); !got.Equal(want) {
+		t.Errorf("Client should be at %v, got %v", want, got)
+	}
+	select {
+	case err := <-slept:
+		if err != nil {
+			t.Errorf("Sleep should succeed, got %v", err)
+		}
+	default:
+		t.Errorf("Sleeper should have resumed")
+	}
+	if got := srv.Clients(); got != 1 {
+		t.Errorf("Should have 1 client, got %d", got)
+	}
+
+	srv.Close()
+	if err := <-served; !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Serve should return net.ErrClosed, got %v", err)
+	}
+	<-client.done
+	if err := client.Clock.SleepWithContext(context.Background(), time.Second); !errors.Is(err, crown.ErrClockClosed) {
+		t.Errorf("Client clock should be closed once disconnected, got %v", err)
+	}
+}
+
+func TestSlowClient(t *testing.T) {
+	srv, err := Listen("unix", filepath.Join(t.TempDir(), "clock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	clock := crown.NewClock(time.Now(), srv.Option())
+	go srv.Serve()
+
+	// Read the initial time, but never acknowledge it.
+	conn, err := net.Dial("unix", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Read(make([]byte, 256)); err != nil {
+		t.Fatal(err)
+	}
+
+	advanced := make(chan struct{})
+	go func() {
+		clock.Forward(time.Second)
+		close(advanced)
+	}()
+	select {
+	case <-advanced:
+	case <-time.After(time.Second):
+		t.Fatal("Forward should not wait for a connecting client")
+	}
+	if got := srv.Clients(); got != 0 {
+		t.Errorf("Should have no client yet, got %d", got)
+	}
+}
+
+func TestScaledClock(t *testing.T) {
+	srv, err := Listen("unix", filepath.Join(t.TempDir(), "clock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	clock := crown.NewWallClock(srv.Option())
+	defer clock.Close()
+	go srv.Serve()
+
+	for i := 0; i < 2; i++ {
+		client, err := Dial("unix", srv.listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.Clients() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Should connect 2 clients, got %d", srv.Clients())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
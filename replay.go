@@ -0,0 +1,67 @@
+package crown
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Replay moves c like the clock whose events were recorded in events,
+// typically by an EventLog created with NewEventLog(0), possibly exported and
+// read back with the timeline package, so that a failing fuzz or simulation
+// run can be replayed bit for bit against modified code.
+//
+// Replay re-applies the recorded advances in order, each by the same duration,
+// once as many waits are pending on c as before it was recorded, after
+// yielding to the other goroutines: the code under test is then expected to
+// be in the same state as during the recording. The other events only tell
+// how many waits were pending. c is expected to start at the time the
+// recorded clock started at, and to be moved by Replay only.
+//
+// Replay returns nil once every advance is replayed. If the pending waits
+// never match the recording, because the code under test diverged, it returns
+// when ctx is done, with an error wrapping ctx's error and describing where
+// the replay stopped.
+func (c *Clock) Replay(ctx context.Context, events []Event) error {
+	advances := 0
+	for _, e := range events {
+		if e.Kind == EventAdvance {
+			advances++
+		}
+	}
+	pending, replayed := 0, 0
+	for _, e := range events {
+		switch e.Kind {
+		case EventRegister:
+			pending++
+		case EventFire, EventCancel:
+			pending--
+		case EventAdvance:
+			if err := c.awaitWaiters(ctx, pending); err != nil {
+				return fmt.Errorf("crown: replay of advance %d of %d to %s: %d wait(s) pending, want %d: %w",
+					replayed+1, advances, e.Time.Format(time.RFC3339Nano), c.Waiters(), pending, err)
+			}
+			d := e.Advance
+			c.advance(c.wakeAck, func(now time.Time) time.Time {
+				return now.Add(d)
+			})
+			replayed++
+		}
+	}
+	return nil
+}
+
+// awaitWaiters blocks until exactly n waits are pending on the clock, and
+// stay so after yielding to the other goroutines. It returns ctx's error if
+// ctx is done first.
+func (c *Clock) awaitWaiters(ctx context.Context, n int) error {
+	for {
+		if err := c.BlockUntilContext(ctx, n); err != nil {
+			return err
+		}
+		c.settle()
+		if c.Waiters() == n {
+			return nil
+		}
+	}
+}
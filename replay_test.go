@@ -0,0 +1,59 @@
+package crown
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wakeUps runs a worker sleeping n times on clock, and returns the times it
+// woke up at once it is done.
+func wakeUps(clock *Clock, n int) <-chan []time.Time {
+	done := make(chan []time.Time, 1)
+	go func() {
+		var times []time.Time
+		for i := 1; i <= n; i++ {
+			clock.Sleep(time.Duration(i) * time.Second)
+			times = append(times, clock.Now())
+		}
+		done <- times
+	}()
+	return done
+}
+
+func TestReplay(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-12T09:00:00Z")
+	log := NewEventLog(0)
+	clock := NewClock(refT, WithWakeAck(), WithEventLog(log))
+	done := wakeUps(clock, 3)
+	for _, d := range []time.Duration{1500 * time.Millisecond, 3 * time.Second, 7 * time.Second} {
+		clock.BlockUntil(1)
+		clock.Forward(d)
+	}
+	want := <-done
+
+	replay := NewClock(refT, WithWakeAck())
+	done = wakeUps(replay, 3)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := replay.Replay(ctx, log.Events()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-done; !reflect.DeepEqual(got, want) {
+		t.Errorf("Should wake up at %v, got %v", want, got)
+	}
+
+	// The modified worker sleeps twice only.
+	diverged := NewClock(refT, WithWakeAck())
+	done = wakeUps(diverged, 2)
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := diverged.Replay(ctx, log.Events())
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "advance 3 of 3 to 2023-01-12T09:00:11.5Z: 0 wait(s) pending, want 1") {
+		t.Errorf("Should report the divergence, got %v", err)
+	}
+	<-done
+}
@@ -0,0 +1,117 @@
+package crown
+
+import "time"
+
+// WithTimeScale makes the clock follow the wall time multiplied by factor:
+// with a factor of 60, the clock moves by a minute every wall second. Sleepers,
+// timers and tickers are released by the clock itself when their deadline is
+// reached, so that soak tests and demos run faster than real time without
+// calls to Forward. The clock can still be moved by hand, and then follows
+// the wall time from where it was moved to. Pause and Resume stop and restart
+// the flow of time. The factor must be greater than zero; if not,
+// WithTimeScale panics.
+func WithTimeScale(factor float64) Option {
+	if factor <= 0 {
+		panic("crown: non-positive factor for WithTimeScale")
+	}
+	return func(c *Clock) {
+		c.factor = factor
+	}
+}
+
+// NewWallClock returns a new Clock which follows the system clock, starting at
+// the current wall time, like a clock created with WithTimeScale(1). Once
+// paused with Pause, it becomes fully manual, so that a test can run its setup
+// in real time, then control its timeouts with Forward or Set. Resume makes it
+// follow the wall time again, from its current time.
+func NewWallClock(opts ...Option) *Clock {
+	return NewClock(time.Now(), append(opts[:len(opts):len(opts)], WithTimeScale(1))...)
+}
+
+// instant is the current time of a clock. If factor is not zero, the clock
+// follows the wall time, see WithTimeScale: it was at t at the wall time wall.
+type instant struct {
+	t      time.Time
+	wall   time.Time
+	factor float64
+}
+
+// at returns the time of the clock at the wall time wall.
+func (i *instant) at(wall time.Time) time.Time {
+	return i.t.Add(time.Duration(float64(wall.Sub(i.wall)) * i.factor))
+}
+
+// flowingNow returns the current time of a clock following the wall time, or
+// the zero time for a zero Clock.
+func (c *Clock) flowingNow() time.Time {
+	now, _ := c.load()
+	return now
+}
+
+// Pause stops the flow of time of a clock created with WithTimeScale: the
+// clock stays at its current time until Resume is called, and only moves when
+// moved by hand in the meantime. Pause has no effect on other clocks, or if
+// the clock is already paused.
+func (c *Clock) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scaleStop == nil {
+		return
+	}
+	now, _ := c.load()
+	c.current.Store(&instant{t: now})
+	close(c.scaleStop)
+	c.scaleStop = nil
+}
+
+// Resume makes a clock created with WithTimeScale follow the wall time again,
+// from its current time. Resume has no effect on other clocks, if the clock
+// is not paused, or if it is closed.
+func (c *Clock) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.factor == 0 || c.scaleStop != nil || c.isClosed() {
+		return
+	}
+	now, _ := c.load()
+	c.current.Store(&instant{t: now, wall: time.Now(), factor: c.factor})
+	c.scaleStop = make(chan struct{})
+	go c.followWall(c.scaleStop)
+}
+
+// Paused reports whether a clock created with WithTimeScale is paused. It
+// reports true for other clocks, whose time never flows by itself.
+func (c *Clock) Paused() bool {
+	i, _ := c.current.Load().(*instant)
+	return i == nil || i.factor == 0
+}
+
+// followWall runs the goroutine releasing the waits of a clock following the
+// wall time, until stop is closed.
+func (c *Clock) followWall(stop chan struct{}) {
+	for {
+		changed := c.waitersChanged()
+		var wake <-chan time.Time
+		var timer *time.Timer
+		if next, ok := c.NextDeadline(); ok {
+			timer = time.NewTimer(time.Duration(float64(next.Sub(c.Now())) / c.factor))
+			wake = timer.C
+		}
+		select {
+		case <-wake:
+			c.advance(c.wakeAck, func(now time.Time) time.Time {
+				return now
+			})
+		case <-changed:
+		case <-stop:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+	}
+}
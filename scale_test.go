@@ -0,0 +1,104 @@
+package crown
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeScale(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-07T09:00:00Z")
+	// A wall millisecond is 1000 seconds of the clock.
+	clock := NewClock(refT, WithTimeScale(1e6))
+	defer clock.Close()
+	if clock.Paused() {
+		t.Fatalf("Clock should follow the wall time")
+	}
+
+	wallStart := time.Now()
+	clock.Sleep(24 * time.Hour)
+	if got := time.Since(wallStart); got < 86*time.Millisecond {
+		t.Errorf("Sleep of a day should last at least 86ms, lasted %s", got)
+	}
+	if got := clock.Now(); got.Before(refT.Add(24 * time.Hour)) {
+		t.Errorf("Should be a day later, got %q", got)
+	}
+
+	clock.Pause()
+	if !clock.Paused() {
+		t.Fatalf("Clock should be paused")
+	}
+	paused := clock.Now()
+	timer := clock.NewTimer(time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if got := clock.Now(); !got.Equal(paused) {
+		t.Errorf("Paused clock should stay at %q, got %q", paused, got)
+	}
+	select {
+	case <-timer.C:
+		t.Fatalf("Timer fired while the clock is paused")
+	default:
+	}
+
+	// The clock moved by hand follows the wall time from there once resumed.
+	clock.Set(refT)
+	clock.Resume()
+	select {
+	case <-timer.C:
+		t.Fatalf("Timer fired although the clock was set back")
+	case <-time.After(time.Millisecond):
+	}
+	if got := clock.Now(); !got.After(refT) || !got.Before(paused) {
+		t.Errorf("Should follow the wall time from %q, got %q", refT, got)
+	}
+}
+
+func TestTimeScaleTimer(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-07T09:00:00Z")
+	clock := NewClock(refT, WithTimeScale(1000))
+	timer := clock.NewTimer(10 * time.Second)
+	select {
+	case got := <-timer.C:
+		if want := refT.Add(10 * time.Second); got.Before(want) {
+			t.Errorf("Should fire after %q, got %q", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timer did not fire")
+	}
+	clock.Close()
+	if !clock.Paused() {
+		t.Errorf("Closed clock should be paused")
+	}
+	clock.Resume()
+	if !clock.Paused() {
+		t.Errorf("Closed clock should not resume")
+	}
+}
+
+func TestWallClock(t *testing.T) {
+	clock := NewWallClock(WithWakeAck())
+	defer clock.Close()
+	if d := time.Since(clock.Now()); d < 0 || d > time.Second {
+		t.Errorf("Should follow the wall time, %s apart", d)
+	}
+	time.Sleep(time.Millisecond)
+	clock.Sleep(time.Millisecond)
+
+	clock.Pause()
+	paused := clock.Now()
+	timer := clock.NewTimer(time.Minute)
+	clock.Forward(time.Minute)
+	if got, want := clock.Now(), paused.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("Should be at %q, got %q instead", want, got)
+	}
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer did not fire on Forward")
+	}
+
+	clock.Resume()
+	time.Sleep(time.Millisecond)
+	if got, min := clock.Now(), paused.Add(time.Minute+time.Millisecond); got.Before(min) {
+		t.Errorf("Should be after %q, got %q", min, got)
+	}
+}
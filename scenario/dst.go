@@ -0,0 +1,89 @@
+package scenario
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// ErrNoTransition is the error returned by ToTransition when a location has no
+// transition in the year to come.
+var ErrNoTransition = errors.New("scenario: no time zone transition within a year")
+
+// NextTransition returns the next transition of the offset of loc after t,
+// such as the start or the end of daylight-saving time, and reports false if
+// there is none within a year.
+func NextTransition(loc *time.Location, t time.Time) (time.Time, bool) {
+	_, offset := t.In(loc).Zone()
+	changed := func(u time.Time) bool {
+		_, o := u.In(loc).Zone()
+		return o != offset
+	}
+	// Transitions happen at whole seconds: find the hour, then the second.
+	lo := t.Truncate(time.Second)
+	hi := lo.Add(time.Hour)
+	for !changed(hi) {
+		if hi.Sub(t) > 366*24*time.Hour {
+			return time.Time{}, false
+		}
+		lo, hi = hi, hi.Add(time.Hour)
+	}
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if changed(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi.In(loc), true
+}
+
+// ToTransition moves c to d before the next transition of the offset of loc
+// at least d away, see NextTransition, and returns the time of the
+// transition, in loc. Like Clock.Set, it releases the waits due on the way.
+// The test then steps across the transition with Forward. ToTransition
+// returns ErrNoTransition, without moving c, if there is no transition within
+// a year.
+func ToTransition(c *crown.Clock, loc *time.Location, d time.Duration) (time.Time, error) {
+	transition, ok := NextTransition(loc, c.Now().Add(d))
+	if !ok {
+		return time.Time{}, ErrNoTransition
+	}
+	c.Set(transition.Add(-d))
+	return transition, nil
+}
+
+// WallDuration returns the difference between the wall-clock readings of t
+// and u in loc: across the start of daylight-saving time, an absolute hour
+// reads as two hours, and across its end as none.
+func WallDuration(loc *time.Location, t, u time.Time) time.Duration {
+	return wall(u.In(loc)).Sub(wall(t.In(loc)))
+}
+
+// wall returns the wall-clock reading of t, as a UTC time.
+func wall(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), time.UTC)
+}
+
+// VerifyAbsolute fails the test unless got is exactly d after start, in
+// absolute time, such as for a timeout.
+func VerifyAbsolute(t testing.TB, start, got time.Time, d time.Duration) {
+	t.Helper()
+	if elapsed := got.Sub(start); elapsed != d {
+		t.Errorf("scenario: %s is %s after %s in absolute time, want %s", got, elapsed, start, d)
+	}
+}
+
+// VerifyWall fails the test unless the wall-clock reading of got in loc is d
+// after that of start, such as for a job scheduled at a time of the day.
+func VerifyWall(t testing.TB, loc *time.Location, start, got time.Time, d time.Duration) {
+	t.Helper()
+	if elapsed := WallDuration(loc, start, got); elapsed != d {
+		t.Errorf("scenario: %s is %s after %s in wall-clock time in %s, want %s", got.In(loc), elapsed, start.In(loc), loc, d)
+	}
+}
@@ -0,0 +1,79 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/enzzc/crown"
+)
+
+func TestToTransition(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refT, _ := time.Parse(time.RFC3339, "2023-01-22T09:00:00Z")
+	c := crown.NewClock(refT)
+	transition, err := ToTransition(c, paris, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := transition.Format(time.RFC3339), "2023-03-26T03:00:00+02:00"; got != want {
+		t.Errorf("Daylight-saving time should start at %s, got %s", want, got)
+	}
+	start := c.Now()
+	if got, want := start.In(paris).Format(time.RFC3339), "2023-03-26T01:59:00+01:00"; got != want {
+		t.Errorf("Clock should be a minute before, at %s, got %s", want, got)
+	}
+
+	// A job every hour, and a job every day at the same time.
+	c.Forward(time.Hour)
+	VerifyAbsolute(t, start, c.Now(), time.Hour)
+	VerifyWall(t, paris, start, c.Now(), 2*time.Hour)
+	tomorrow := start.In(paris).AddDate(0, 0, 1)
+	VerifyAbsolute(t, start, tomorrow, 23*time.Hour)
+	VerifyWall(t, paris, start, tomorrow, 24*time.Hour)
+
+	// The end of daylight-saving time.
+	transition, _ = ToTransition(c, paris, time.Second)
+	if got, want := transition.Format(time.RFC3339), "2023-10-29T02:00:00+01:00"; got != want {
+		t.Errorf("Daylight-saving time should end at %s, got %s", want, got)
+	}
+	if got := WallDuration(paris, c.Now(), c.Now().Add(time.Hour)); got != 0 {
+		t.Errorf("Wall clock should read the same time an hour later, got %s", got)
+	}
+
+	if _, err := ToTransition(c, time.FixedZone("UTC+1", 3600), time.Minute); !errors.Is(err, ErrNoTransition) {
+		t.Errorf("Fixed zone should have no transition, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2023, time.March, 26, 1, 0, 0, 0, paris)
+	r := &recorder{TB: t}
+	VerifyAbsolute(r, start, start.Add(time.Hour), 2*time.Hour)
+	VerifyWall(r, paris, start, start.Add(time.Hour), time.Hour)
+	if len(r.failures) != 2 || !strings.Contains(r.failures[0], "1h0m0s after") || !strings.Contains(r.failures[1], "2h0m0s after 2023-03-26 01:00:00 +0100 CET in wall-clock time in Europe/Paris, want 1h0m0s") {
+		t.Errorf("Should report both failures, got %q", r.failures)
+	}
+}
+
+// recorder records the failures of a test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
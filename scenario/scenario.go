@@ -0,0 +1,47 @@
+// Package scenario injects into crown clocks the anomalies of real clocks,
+// such as leap seconds and clock steps, and moves them across daylight-saving
+// transitions, so that the code which must survive them, such as schedulers,
+// certificate validation and token expiry, can be tested directly.
+package scenario
+
+import (
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// LeapSecond moves c through the next positive leap second, at the end of the
+// UTC day of its current time, the way a system clock without leap smearing
+// does: the time package cannot represent 23:59:60, so the clock reaches
+// midnight, then steps back by a second to repeat 23:59:59 during the leap
+// second. LeapSecond returns the midnight reached, which the clock reaches
+// again once moved by a second, releasing the waits due on the way like
+// Clock.Set. It panics with crown.ErrNonMonotonic if c was created with
+// crown.WithStrictMonotonic.
+func LeapSecond(c *crown.Clock) time.Time {
+	midnight := c.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	c.Set(midnight)
+	c.Set(midnight.Add(-time.Second))
+	return midnight
+}
+
+// StepBack steps c back by d, like a system clock corrected by NTP or by an
+// operator, releasing nothing. It panics with crown.ErrNonMonotonic if c was
+// created with crown.WithStrictMonotonic.
+func StepBack(c *crown.Clock, d time.Duration) {
+	c.Set(c.Now().Add(-d))
+}
+
+// Freeze stops the clock c, which follows the wall time, such as one created
+// with crown.NewWallClock or crown.WithTimeScale, for the wall duration d,
+// then makes it follow the wall time again from where it stopped, so that it
+// stays behind by d, like a stuck hardware clock. Freeze returns once the
+// clock runs again. It panics if c does not follow the wall time.
+func Freeze(c *crown.Clock, d time.Duration) {
+	if c.Paused() {
+		panic("scenario: Freeze of a clock which does not follow the wall time")
+	}
+	c.Pause()
+	time.Sleep(d)
+	c.Resume()
+}
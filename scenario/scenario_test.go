@@ -0,0 +1,63 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestLeapSecond(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2016-12-31T12:00:00Z")
+	c := crown.NewClock(refT)
+	var seen []string
+	c.OnAdvance(func(_, now time.Time) {
+		seen = append(seen, now.Format("15:04:05"))
+	})
+	timer := c.NewTimer(12 * time.Hour)
+	midnight := LeapSecond(c)
+	if want, _ := time.Parse(time.RFC3339, "2017-01-01T00:00:00Z"); !midnight.Equal(want) {
+		t.Errorf("Leap second should end at %s, got %s", want, midnight)
+	}
+	select {
+	case <-timer.C:
+	default:
+		t.Errorf("Timer due at midnight should fire")
+	}
+	c.Forward(time.Second)
+	if got := c.Now(); !got.Equal(midnight) {
+		t.Errorf("Clock should reach midnight again, got %s", got)
+	}
+	if got, want := seen, []string{"00:00:00", "23:59:59", "00:00:00"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Clock should go through %q, got %q", want, got)
+	}
+}
+
+func TestStepBack(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-21T09:00:00Z")
+	c := crown.NewClock(refT)
+	StepBack(c, time.Minute)
+	if got := c.Since(refT); got != -time.Minute {
+		t.Errorf("Clock should step back by a minute, got %s", got)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	c := crown.NewClock(time.Now(), crown.WithTimeScale(100))
+	defer c.Close()
+	start := time.Now()
+	before := c.Now()
+	Freeze(c, 20*time.Millisecond)
+	frozen := c.Now().Sub(before)
+	if elapsed := time.Since(start); frozen >= elapsed*100 || c.Paused() {
+		t.Errorf("Clock should lose the time frozen, moved by %s in %s", frozen, elapsed)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Freeze of a manual clock should panic")
+		}
+	}()
+	Freeze(crown.NewClock(time.Now()), time.Millisecond)
+}
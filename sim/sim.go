@@ -0,0 +1,218 @@
+// Package sim runs discrete-event simulations on a crown clock: events are
+// functions scheduled at times of the clock, and run in time order, the clock
+// being moved to each event before it runs. The goroutines waiting on the
+// clock, such as the code under simulation, are released along the way, so
+// that they interleave with the events.
+package sim
+
+import (
+	"container/heap"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Scheduler runs the events scheduled on its clock, which it embeds. It is
+// created with New. A Scheduler is safe for concurrent use: events can be
+// scheduled by other events, or by goroutines waiting on the clock.
+type Scheduler struct {
+	*crown.Clock
+
+	mu     sync.Mutex
+	events queue
+	seq    uint64
+}
+
+// New returns a scheduler running its events on the clock c, which is only
+// expected to be moved by the scheduler. Creating c with crown.WithWakeAck
+// makes the goroutines released by a move resume before the next event runs.
+func New(c *crown.Clock) *Scheduler {
+	return &Scheduler{Clock: c}
+}
+
+// Schedule schedules fn to run at the time at of the clock, with priority 0.
+// An event scheduled in the past of the clock runs at its current time,
+// without moving it back.
+func (s *Scheduler) Schedule(at time.Time, fn func()) {
+	s.SchedulePriority(at, 0, fn)
+}
+
+// SchedulePriority is like Schedule, with the given priority: among the events
+// scheduled at the same time, those with a higher priority run first, then
+// those scheduled first, so that runs are reproducible whatever the order of
+// the events in the queue.
+func (s *Scheduler) SchedulePriority(at time.Time, priority int, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	heap.Push(&s.events, &event{at: at, priority: priority, seq: s.seq, fn: fn})
+}
+
+// ScheduleAfter schedules fn to run once the clock has moved by d from its
+// current time, with priority 0.
+func (s *Scheduler) ScheduleAfter(d time.Duration, fn func()) {
+	s.SchedulePriority(s.Now().Add(d), 0, fn)
+}
+
+// ScheduleAfterPriority is like ScheduleAfter, with the given priority, see
+// SchedulePriority.
+func (s *Scheduler) ScheduleAfterPriority(d time.Duration, priority int, fn func()) {
+	s.SchedulePriority(s.Now().Add(d), priority, fn)
+}
+
+// ScheduleBetween schedules fn to run after a random delay between min
+// included and max excluded, drawn from the random numbers of the clock, see
+// crown.Clock.Rand, with priority 0. The clock created with crown.WithRandSeed
+// makes the delays reproducible. ScheduleBetween panics if max is not greater
+// than min.
+func (s *Scheduler) ScheduleBetween(min, max time.Duration, fn func()) {
+	if max <= min {
+		panic("sim: empty delay interval")
+	}
+	s.ScheduleAfter(min+time.Duration(s.Rand().Int63n(int64(max-min))), fn)
+}
+
+// Len returns the number of events scheduled, and not run yet.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events.Len()
+}
+
+// Next returns the time of the next event, and reports false if there is
+// none.
+func (s *Scheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.events.Len() == 0 {
+		return time.Time{}, false
+	}
+	return s.events[0].at, true
+}
+
+// Step runs the next event, moving the clock to its time first, and reports
+// false, without moving the clock, if there is none.
+func (s *Scheduler) Step() bool {
+	return s.step(time.Time{}, false)
+}
+
+// Run runs the events until none is left, including those scheduled by the
+// events themselves, and returns how many ran. It never returns if events keep
+// scheduling new ones.
+func (s *Scheduler) Run() int {
+	n := 0
+	for s.Step() {
+		n++
+	}
+	return n
+}
+
+// RunUntil runs the events scheduled up to the time t included, then moves the
+// clock to t, and returns how many events ran. The clock is not moved back if
+// it is already past t.
+func (s *Scheduler) RunUntil(t time.Time) int {
+	n := 0
+	for {
+		if s.step(t, true) {
+			n++
+			continue
+		}
+		if s.advanceToward(t) {
+			return n
+		}
+	}
+}
+
+// step runs the next event, if any and, if bounded, if it is due by end. It
+// reports whether it ran one.
+func (s *Scheduler) step(end time.Time, bounded bool) bool {
+	for {
+		at, ok := s.Next()
+		if !ok || bounded && at.After(end) {
+			return false
+		}
+		if !s.advanceToward(at) {
+			// The goroutines released may have scheduled earlier events.
+			continue
+		}
+		s.mu.Lock()
+		if s.events.Len() == 0 || s.events[0].at.After(at) {
+			s.mu.Unlock()
+			continue
+		}
+		e := heap.Pop(&s.events).(*event)
+		s.mu.Unlock()
+		e.fn()
+		return true
+	}
+}
+
+// advanceToward moves the clock toward t: to the deadline of the first wait on
+// the clock before t, if any, so that the goroutines it releases observe the
+// same times as with a real clock, and can schedule events before t, or else
+// to t. It reports whether the clock reached t. The clock is not moved back if
+// it is already past t.
+func (s *Scheduler) advanceToward(t time.Time) bool {
+	if !t.After(s.Now()) {
+		return true
+	}
+	if next, ok := s.NextDeadline(); ok && next.Before(t) {
+		s.Set(next)
+		s.settle()
+		return false
+	}
+	s.Set(t)
+	return true
+}
+
+// settle yields to the other goroutines until the number of waits on the
+// clock and of events are stable, so that the goroutines released by a move
+// of the clock schedule their events before the next one runs.
+func (s *Scheduler) settle() {
+	for stable, waiters, events := 0, -1, -1; stable < 3; {
+		runtime.Gosched()
+		if w, e := s.Waiters(), s.Len(); w == waiters && e == events {
+			stable++
+		} else {
+			stable, waiters, events = 0, w, e
+		}
+	}
+}
+
+// event is a function scheduled on a Scheduler.
+type event struct {
+	at       time.Time
+	priority int
+	seq      uint64 // order of scheduling
+	fn       func()
+}
+
+// queue is a heap of events, by time, then decreasing priority, then order of
+// scheduling.
+type queue []*event
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q queue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *queue) Push(x any) { *q = append(*q, x.(*event)) }
+
+func (q *queue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
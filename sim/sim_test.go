@@ -0,0 +1,124 @@
+package sim
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestScheduler(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-13T09:00:00Z")
+	s := New(crown.NewClock(refT, crown.WithWakeAck()))
+	var got []string
+	record := func(name string) func() {
+		return func() {
+			got = append(got, fmt.Sprintf("%s at %s", name, s.Since(refT)))
+		}
+	}
+	s.ScheduleAfter(2*time.Second, record("b"))
+	s.Schedule(refT.Add(time.Second), func() {
+		record("a")()
+		s.ScheduleAfter(time.Second, record("c"))
+	})
+	s.Schedule(refT.Add(time.Hour), record("d"))
+	s.ScheduleAfter(-time.Second, record("past"))
+
+	// A timer of the simulated code fires at its deadline, between events.
+	timer := s.NewTimer(1500 * time.Millisecond)
+
+	if n := s.RunUntil(refT.Add(time.Minute)); n != 4 {
+		t.Errorf("RunUntil should run 4 events, got %d", n)
+	}
+	if fired := <-timer.C; !fired.Equal(refT.Add(1500 * time.Millisecond)) {
+		t.Errorf("Timer should fire at its deadline, got %s", fired)
+	}
+	if now := s.Now(); !now.Equal(refT.Add(time.Minute)) {
+		t.Errorf("RunUntil should move the clock to %s, got %s", refT.Add(time.Minute), now)
+	}
+	if next, ok := s.Next(); !ok || !next.Equal(refT.Add(time.Hour)) || s.Len() != 1 {
+		t.Errorf("Should have d left, got %s, %v and %d events", next, ok, s.Len())
+	}
+	if n := s.Run(); n != 1 {
+		t.Errorf("Run should run 1 event, got %d", n)
+	}
+	want := []string{"past at 0s", "a at 1s", "b at 2s", "c at 2s", "d at 1h0m0s"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Should run\n%q\ngot\n%q", want, got)
+	}
+	if s.Step() {
+		t.Errorf("Step should report false once no event is left")
+	}
+}
+
+func TestSchedulePriority(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-13T09:00:00Z")
+	s := New(crown.NewClock(refT))
+	var got []int
+	for i := 0; i < 100; i++ {
+		i := i
+		s.SchedulePriority(refT.Add(time.Second), i%3, func() {
+			got = append(got, i)
+		})
+	}
+	s.ScheduleAfterPriority(0, -1, func() {
+		got = append(got, -1)
+	})
+	s.Run()
+	want := []int{-1} // earlier
+	for p := 2; p >= 0; p-- {
+		for i := p; i < 100; i += 3 {
+			want = append(want, i)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Should run by time, priority then order of scheduling, got %v", got)
+	}
+}
+
+func TestScheduleBetween(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-13T09:00:00Z")
+	run := func(seed int64) []time.Duration {
+		s := New(crown.NewClock(refT, crown.WithRandSeed(seed)))
+		var delays []time.Duration
+		for i := 0; i < 10; i++ {
+			s.ScheduleBetween(time.Second, 2*time.Second, func() {
+				delays = append(delays, s.Since(refT))
+			})
+		}
+		s.Run()
+		return delays
+	}
+	got := run(1)
+	for _, d := range got {
+		if d < time.Second || d >= 2*time.Second {
+			t.Errorf("Delay should be between 1s and 2s, got %s", d)
+		}
+	}
+	if again := run(1); !reflect.DeepEqual(got, again) {
+		t.Errorf("Should reproduce the delays from the seed, got %v and %v", got, again)
+	}
+}
+
+func TestEventsScheduledByGoroutines(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-13T09:00:00Z")
+	s := New(crown.NewClock(refT, crown.WithWakeAck()))
+	var got []string
+	record := func(name string) func() {
+		return func() {
+			got = append(got, fmt.Sprintf("%s at %s", name, s.Since(refT)))
+		}
+	}
+	s.ScheduleAfter(5*time.Second, record("a"))
+	go func() {
+		s.Sleep(time.Second)
+		s.ScheduleAfter(time.Second, record("b"))
+	}()
+	s.BlockUntil(1)
+	s.Run()
+	if want := []string{"b at 2s", "a at 5s"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Should run %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,101 @@
+package crown
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is a checkpoint of the state of a clock: its time and the waits
+// pending on it, see Clock.Snapshot.
+//
+// A snapshot can be encoded in JSON, to be inspected by external tooling or
+// to persist a simulation across processes: a process can resume it from a
+// clock created with NewClock(s.Time()), the code under test registering its
+// waits again according to s.Pending().
+type Snapshot struct {
+	time    time.Time
+	lastID  uint64 // last key given when the snapshot was taken
+	pending []PendingWait
+}
+
+// Time returns the time of the clock when the snapshot was taken.
+func (s *Snapshot) Time() time.Time {
+	return s.time
+}
+
+// Pending returns the waits pending on the clock when the snapshot was taken,
+// sorted like Clock.Pending.
+func (s *Snapshot) Pending() []PendingWait {
+	return append([]PendingWait(nil), s.pending...)
+}
+
+// snapshotJSON is the JSON encoding of a Snapshot.
+type snapshotJSON struct {
+	Time    time.Time     `json:"time"`
+	LastID  uint64        `json:"last_id"`
+	Pending []PendingWait `json:"pending"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(snapshotJSON{Time: s.time, LastID: s.lastID, Pending: s.pending})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var v snapshotJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Snapshot{time: v.Time, lastID: v.LastID, pending: v.Pending}
+	return nil
+}
+
+// Snapshot returns a checkpoint of the clock, to which Restore can rewind it.
+func (c *Clock) Snapshot() *Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &Snapshot{
+		time:    c.Now(),
+		lastID:  atomic.LoadUint64(&c.lastID),
+		pending: c.Pending(),
+	}
+}
+
+// Restore rewinds the clock to the checkpoint s, taken by Snapshot on the same
+// clock: the clock moves back to the time of s, releasing nothing, and the
+// waits registered since s was taken are canceled, failing with ErrCanceled.
+// The code under test is then expected to restore its own state from the same
+// checkpoint, so that a simulation can branch into several what-if runs.
+//
+// The waits of s released since it was taken cannot be registered again on
+// behalf of their goroutines, which have moved on: Restore reports them in
+// its error, the clock being restored otherwise. Restore panics with
+// ErrNonMonotonic if the clock was created with WithStrictMonotonic and s is
+// in its past.
+func (c *Clock) Restore(s *Snapshot) error {
+	c.advance(false, func(time.Time) time.Time {
+		return s.time
+	})
+	pending := make(map[int64]bool)
+	for _, handler := range c.pendingHandlers() {
+		if handler.id > s.lastID {
+			c.release(handler.id, handler, ErrCanceled)
+			continue
+		}
+		pending[int64(handler.id)] = true
+	}
+	var lost []string
+	for _, w := range s.pending {
+		if !pending[w.ID] {
+			lost = append(lost, fmt.Sprintf("%s %d until %s", w.Kind, w.ID, w.Deadline.Format(time.RFC3339Nano)))
+		}
+	}
+	if len(lost) > 0 {
+		return fmt.Errorf("crown: %d wait(s) of the snapshot released since: %s", len(lost), strings.Join(lost, ", "))
+	}
+	return nil
+}
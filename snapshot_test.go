@@ -0,0 +1,87 @@
+package crown
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-11T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+	early := clock.NewTimer(time.Minute)
+	late := clock.NewTimer(time.Hour)
+	defer late.Stop()
+	clock.Forward(time.Second)
+
+	s := clock.Snapshot()
+	if !s.Time().Equal(refT.Add(time.Second)) || len(s.Pending()) != 2 {
+		t.Fatalf("Should snapshot the time and the 2 pending waits, got %q and %+v", s.Time(), s.Pending())
+	}
+
+	// A what-if branch which does not release the waits of the snapshot.
+	branch := clock.NewTimer(time.Second)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- clock.SleepWithContext(context.Background(), 10*time.Minute)
+	}()
+	clock.BlockUntil(4)
+	clock.Forward(30 * time.Second)
+	<-branch.C
+	if err := clock.Restore(s); err != nil {
+		t.Errorf("Should restore the snapshot, got %v", err)
+	}
+	if got := clock.Now(); !got.Equal(s.Time()) {
+		t.Errorf("Should be back at %q, got %q", s.Time(), got)
+	}
+	if err := <-errs; !errors.Is(err, ErrCanceled) {
+		t.Errorf("Sleep of the branch should be %v, got %v", ErrCanceled, err)
+	}
+	if n := clock.Waiters(); n != 2 {
+		t.Errorf("Should have the 2 waits of the snapshot, got %d", n)
+	}
+
+	// A branch which releases a wait of the snapshot.
+	clock.Forward(time.Minute)
+	<-early.C
+	err := clock.Restore(s)
+	if err == nil || !strings.Contains(err.Error(), "1 wait(s) of the snapshot released since: timer 1 until 2023-01-11T09:01:00Z") {
+		t.Errorf("Should report the released timer, got %v", err)
+	}
+	if got := clock.Now(); !got.Equal(s.Time()) {
+		t.Errorf("Should be back at %q, got %q", s.Time(), got)
+	}
+}
+
+func TestSnapshotJSON(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-11T09:00:00Z")
+	clock := NewClock(refT)
+	timer := clock.NewTimer(time.Minute)
+	defer timer.Stop()
+	clock.Forward(time.Second)
+	data, err := json.Marshal(clock.Snapshot())
+	if err != nil {
+		t.Fatalf("Should marshal the snapshot, got %v", err)
+	}
+	want := `{"time":"2023-01-11T09:00:01Z","last_id":1,"pending":[{"ID":1,"Kind":"timer","Trace":"",` +
+		`"Start":"2023-01-11T09:00:00Z","Age":1000000000,"Deadline":"2023-01-11T09:01:00Z","Stack":"","Goroutine":0}]}`
+	if string(data) != want {
+		t.Errorf("Should marshal to:\n%s\ngot:\n%s", want, data)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Should unmarshal the snapshot, got %v", err)
+	}
+	clock.NewTimer(time.Second)
+	clock.Forward(time.Hour)
+	if err := clock.Restore(&s); err == nil {
+		t.Errorf("Should report the released timer")
+	}
+	if got := clock.Now(); !got.Equal(refT.Add(time.Second)) {
+		t.Errorf("Should be back at the time of the snapshot, got %q", got)
+	}
+}
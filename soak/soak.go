@@ -0,0 +1,188 @@
+// Package soak runs systems timed by a crown clock over long spans of
+// simulated time, such as years, as fast as possible, while sampling
+// invariants and reporting the anomalies along with the simulated time at
+// which they occurred.
+package soak
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// Invariant is a property of the system under test, checked periodically.
+type Invariant struct {
+	Name string
+	// Check returns an error if the property does not hold at the time now
+	// of the clock.
+	Check func(now time.Time) error
+}
+
+// Config configures a soak test.
+type Config struct {
+	// Duration is the span of simulated time to run for.
+	Duration time.Duration
+	// Step is the size of each advance of the clock. If zero, the clock
+	// jumps from one deadline to the next.
+	Step time.Duration
+	// SampleEvery is the period, in simulated time, at which the invariants
+	// are checked. It defaults to a day.
+	SampleEvery time.Duration
+	// Invariants are the properties checked every SampleEvery.
+	Invariants []Invariant
+	// MaxDrift, if positive, is how late a wait can be released after its
+	// deadline without being reported.
+	MaxDrift time.Duration
+	// MaxWaiters, if positive, is the number of pending waits above which
+	// the queue of the clock is reported as growing unbounded.
+	MaxWaiters int
+	// MaxAnomalies is the number of anomalies after which the run stops. It
+	// defaults to 100.
+	MaxAnomalies int
+}
+
+// Anomaly is a violation observed during a soak test.
+type Anomaly struct {
+	// Time is the time of the clock when the anomaly was observed.
+	Time time.Time
+	// Name is the name of the violated invariant, or "drift" or "waiters"
+	// for the built-in checks.
+	Name string
+	Err  error
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s: %s: %v", a.Time.Format(time.RFC3339Nano), a.Name, a.Err)
+}
+
+// Report summarizes a soak test.
+type Report struct {
+	Start, End time.Time
+	Advances   int
+	Stats      crown.Stats
+	Anomalies  []Anomaly
+}
+
+// OK reports whether no anomaly was observed.
+func (r *Report) OK() bool {
+	return len(r.Anomalies) == 0
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "soak: %s to %s (%s), %d advances, %d fires, at most %d waiters, %d anomalies",
+		r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.End.Sub(r.Start),
+		r.Advances, r.Stats.Fires, r.Stats.MaxWaiters, len(r.Anomalies))
+	for _, a := range r.Anomalies {
+		b.WriteString("\n\t")
+		b.WriteString(a.String())
+	}
+	return b.String()
+}
+
+// Run creates a clock starting at start with opts, starts the system under
+// test by calling setup, and advances the clock until cfg.Duration has
+// elapsed, or MaxAnomalies anomalies were observed. The clock is closed
+// before Run returns.
+//
+// The clock waits for its released sleepers to resume after each advance
+// (see crown.WithWakeAck), and Run lets the goroutines of the system
+// register their next waits before advancing again.
+func Run(start time.Time, cfg Config, setup func(c *crown.Clock), opts ...crown.Option) *Report {
+	if cfg.SampleEvery <= 0 {
+		cfg.SampleEvery = 24 * time.Hour
+	}
+	if cfg.MaxAnomalies <= 0 {
+		cfg.MaxAnomalies = 100
+	}
+	r := &runner{cfg: cfg, report: &Report{Start: start}}
+	opts = append(opts[:len(opts):len(opts)], crown.WithWakeAck(), crown.WithObserver(r.observe))
+	c := crown.NewClock(start, opts...)
+	defer c.Close()
+	setup(c)
+
+	end := start.Add(cfg.Duration)
+	nextSample := start.Add(cfg.SampleEvery)
+	for !r.full() {
+		settle(c)
+		now := c.Now()
+		if !now.Before(end) {
+			break
+		}
+		target := nextSample
+		if cfg.Step > 0 {
+			target = now.Add(cfg.Step)
+		} else if pending := c.Pending(); len(pending) > 0 && pending[0].Deadline.Before(target) {
+			target = pending[0].Deadline
+		}
+		if target.After(end) {
+			target = end
+		}
+		c.Forward(target.Sub(now))
+		r.report.Advances++
+		now = c.Now()
+		if cfg.MaxWaiters > 0 {
+			if n := c.Waiters(); n > cfg.MaxWaiters {
+				r.add(Anomaly{Time: now, Name: "waiters", Err: fmt.Errorf("%d pending waits, more than %d", n, cfg.MaxWaiters)})
+			}
+		}
+		for !now.Before(nextSample) {
+			settle(c)
+			for _, inv := range cfg.Invariants {
+				if err := inv.Check(now); err != nil {
+					r.add(Anomaly{Time: now, Name: inv.Name, Err: err})
+				}
+			}
+			nextSample = nextSample.Add(cfg.SampleEvery)
+		}
+	}
+	r.report.End = c.Now()
+	r.report.Stats = c.Stats()
+	return r.report
+}
+
+type runner struct {
+	cfg    Config
+	mu     sync.Mutex
+	report *Report
+}
+
+func (r *runner) observe(e crown.Event) {
+	if e.Kind != crown.EventFire || r.cfg.MaxDrift <= 0 {
+		return
+	}
+	if late := e.Time.Sub(e.Deadline); late > r.cfg.MaxDrift {
+		r.add(Anomaly{Time: e.Time, Name: "drift", Err: fmt.Errorf("%s %d released %s after its deadline", e.Wait, e.ID, late)})
+	}
+}
+
+func (r *runner) add(a Anomaly) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.report.Anomalies) < r.cfg.MaxAnomalies {
+		r.report.Anomalies = append(r.report.Anomalies, a)
+	}
+}
+
+func (r *runner) full() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.report.Anomalies) >= r.cfg.MaxAnomalies
+}
+
+// settle yields to the goroutines of the system until the number of waits
+// pending on c is stable.
+func settle(c *crown.Clock) {
+	for stable, last := 0, -1; stable < 3; {
+		runtime.Gosched()
+		if n := c.Waiters(); n == last {
+			stable++
+		} else {
+			stable, last = 0, n
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package soak
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestRun(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2023-01-01T00:00:00Z")
+	var runs int64
+	setup := func(c *crown.Clock) {
+		// A daily job, scheduled with a ticker.
+		ticker := c.NewTicker(24 * time.Hour)
+		go func() {
+			for range ticker.C {
+				atomic.AddInt64(&runs, 1)
+			}
+		}()
+	}
+	broken := start.AddDate(1, 6, 0)
+	report := Run(start, Config{
+		Duration:    2 * 365 * 24 * time.Hour,
+		SampleEvery: 7 * 24 * time.Hour,
+		MaxDrift:    time.Second,
+		MaxWaiters:  10,
+		Invariants: []Invariant{{
+			Name: "daily job",
+			Check: func(now time.Time) error {
+				if now.After(broken) {
+					return errors.New("stopped running")
+				}
+				return nil
+			},
+		}},
+		MaxAnomalies: 1,
+	}, setup)
+
+	if report.OK() {
+		t.Fatalf("Should report the broken invariant: %v", report)
+	}
+	a := report.Anomalies[0]
+	if a.Name != "daily job" || a.Time.Before(broken) || a.Time.Sub(broken) > 7*24*time.Hour {
+		t.Errorf("Should report the invariant within a week after %q, got %v", broken, a)
+	}
+	if !report.End.Equal(a.Time) {
+		t.Errorf("Should stop at the anomaly, stopped at %q", report.End)
+	}
+	if got, want := atomic.LoadInt64(&runs), int64(report.End.Sub(start)/(24*time.Hour)); got < want-1 {
+		t.Errorf("Should run the job about %d times, got %d", want, got)
+	}
+}
+
+func TestRunDrift(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2023-01-01T00:00:00Z")
+	report := Run(start, Config{
+		Duration: 10 * time.Hour,
+		Step:     time.Hour,
+		MaxDrift: time.Minute,
+	}, func(c *crown.Clock) {
+		c.NewTimer(90 * time.Minute)
+	})
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Name != "drift" {
+		t.Fatalf("Should report the late timer: %v", report)
+	}
+	if want := start.Add(2 * time.Hour); !report.Anomalies[0].Time.Equal(want) {
+		t.Errorf("Should report the drift at %q, got %v", want, report.Anomalies[0])
+	}
+}
@@ -0,0 +1,76 @@
+package crown
+
+import "time"
+
+// Stats holds statistics about the activity of a Clock.
+type Stats struct {
+	// Waiters is the number of waits currently blocked on the clock.
+	Waiters int
+	// MaxWaiters is the highest number of waits simultaneously blocked on
+	// the clock.
+	MaxWaiters int
+	// Sleeps is the total number of waits started on the clock, including
+	// those which returned immediately.
+	Sleeps int64
+	// Fires is the number of waits released because the clock reached their
+	// deadline.
+	Fires int64
+	// Cancellations is the number of waits interrupted before their
+	// deadline, by their context, a stopped timer or a closed clock.
+	Cancellations int64
+	// Advances is the number of times the clock was moved.
+	Advances int64
+	// Advanced is the total duration the clock moved forward by. Backward
+	// moves are not subtracted.
+	Advanced time.Duration
+	// LastAdvance is the duration of the last move of the clock.
+	LastAdvance time.Duration
+	// LastAdvanceAt is the time of the clock right after its last move.
+	LastAdvanceAt time.Time
+}
+
+// Stats returns a snapshot of the statistics of the clock.
+func (c *Clock) Stats() Stats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	s := c.stats
+	s.Waiters = c.Waiters()
+	return s
+}
+
+func (c *Clock) statSleep() {
+	c.statsMu.Lock()
+	c.stats.Sleeps++
+	c.statsMu.Unlock()
+}
+
+func (c *Clock) statRegister(waiters int32) {
+	c.statsMu.Lock()
+	if int(waiters) > c.stats.MaxWaiters {
+		c.stats.MaxWaiters = int(waiters)
+	}
+	c.statsMu.Unlock()
+}
+
+// statRelease accounts for a deregistered wait, which was interrupted unless
+// err is nil.
+func (c *Clock) statRelease(err error) {
+	c.statsMu.Lock()
+	if err == nil {
+		c.stats.Fires++
+	} else {
+		c.stats.Cancellations++
+	}
+	c.statsMu.Unlock()
+}
+
+func (c *Clock) statAdvance(d time.Duration, now time.Time) {
+	c.statsMu.Lock()
+	c.stats.Advances++
+	if d > 0 {
+		c.stats.Advanced += d
+	}
+	c.stats.LastAdvance = d
+	c.stats.LastAdvanceAt = now
+	c.statsMu.Unlock()
+}
@@ -0,0 +1,46 @@
+package crown
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	clock.Sleep(0)
+	timer1 := clock.NewTimer(time.Second)
+	timer2 := clock.NewTimer(time.Second)
+	go clock.SleepWithContext(ctx, time.Minute)
+	waitForSleepers(t, clock, 4, 10)
+
+	timer2.Stop()
+	for clock.Waiters() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(2 * time.Second)
+	<-timer1.C
+	cancel()
+	for clock.Waiters() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	got := clock.Stats()
+	want := Stats{
+		Waiters:       0,
+		MaxWaiters:    3,
+		Sleeps:        4,
+		Fires:         1,
+		Cancellations: 2,
+		Advances:      1,
+		Advanced:      2 * time.Second,
+		LastAdvance:   2 * time.Second,
+		LastAdvanceAt: refT.Add(2 * time.Second),
+	}
+	if got != want {
+		t.Errorf("Should be %+v, got %+v instead", want, got)
+	}
+}
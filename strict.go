@@ -0,0 +1,56 @@
+package crown
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// WithStrict makes the clock report every wait registered while none is
+// expected, see Expect: Sleep, SleepWithContext, NewTimer, AfterFunc,
+// NewTicker and WithDeadline calls are then errors, unless allowed
+// beforehand. This catches the code paths which still use the clock when a
+// test believes all timing to be disabled.
+//
+// The error passed to report wraps ErrUnexpectedWait and tells the kind of
+// the wait and the stack which registered it. report is called by the
+// goroutine registering the wait, before it is registered. If report is nil,
+// that goroutine panics with the error instead.
+func WithStrict(report func(err error)) Option {
+	if report == nil {
+		report = func(err error) {
+			panic(err)
+		}
+	}
+	return func(c *Clock) {
+		c.strict = report
+	}
+}
+
+// Expect allows the next n waits registered on a clock created with
+// WithStrict. The ticks of a ticker count as a single wait, while resetting
+// a timer or a ticker counts as a new one. Expect has no effect on other
+// clocks.
+func (c *Clock) Expect(n int) {
+	atomic.AddInt64(&c.expected, int64(n))
+}
+
+// checkExpected accounts for a wait of the given kind being registered, and
+// reports it unless it was expected.
+func (c *Clock) checkExpected(kind string) {
+	for {
+		n := atomic.LoadInt64(&c.expected)
+		if n <= 0 {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&c.expected, n, n-1) {
+			return
+		}
+	}
+	pcs := make([]uintptr, 32)
+	var b strings.Builder
+	writeStack(&b, pcs[:runtime.Callers(3, pcs)])
+	c.strict(fmt.Errorf("%w: %s registered at %s by:\n%s", ErrUnexpectedWait, kind, c.Now().Format(time.RFC3339Nano), b.String()))
+}
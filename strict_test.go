@@ -0,0 +1,52 @@
+package crown
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStrict(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-10T09:00:00Z")
+	var reported []error
+	clock := NewClock(refT, WithStrict(func(err error) {
+		reported = append(reported, err)
+	}))
+
+	clock.Expect(2)
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(time.Second)
+	clock.Forward(time.Minute)
+	if len(reported) != 0 {
+		t.Fatalf("Should not report expected waits, got %v", reported)
+	}
+
+	timer.Reset(time.Minute)
+	ticker.Stop()
+	if len(reported) != 1 {
+		t.Fatalf("Should report the unexpected Reset, got %v", reported)
+	}
+	if !errors.Is(reported[0], ErrUnexpectedWait) {
+		t.Errorf("Should be %v, got %v instead", ErrUnexpectedWait, reported[0])
+	}
+	if msg := reported[0].Error(); !strings.Contains(msg, "timer registered at 2023-01-10T09:01:00Z") || !strings.Contains(msg, "crown.TestStrict()") {
+		t.Errorf("Should describe the wait and its origin, got:\n%s", msg)
+	}
+	timer.Stop()
+}
+
+func TestStrictPanics(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-10T09:00:00Z")
+	clock := NewClock(refT, WithStrict(nil))
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrUnexpectedWait) {
+			t.Errorf("Should panic with %v, got %v", ErrUnexpectedWait, err)
+		}
+		if n := clock.Waiters(); n != 0 {
+			t.Errorf("Should not register the wait, got %d waiters", n)
+		}
+	}()
+	clock.Sleep(time.Second)
+}
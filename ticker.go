@@ -0,0 +1,274 @@
+package crown
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// MissedTickPolicy defines how a Ticker behaves when a single clock advance
+// spans several of its periods.
+type MissedTickPolicy int
+
+const (
+	// CatchUpAll delivers one tick for every elapsed period, each carrying
+	// its scheduled time. This is the default policy.
+	CatchUpAll MissedTickPolicy = iota
+	// FireOnce delivers a single tick carrying the current time, and
+	// reschedules the next tick one full period after it.
+	FireOnce
+	// Skip drops the ticks that were overtaken: only the tick of the last
+	// elapsed period is delivered, and the ticker stays aligned on its
+	// original schedule.
+	Skip
+)
+
+func (p MissedTickPolicy) String() string {
+	switch p {
+	case CatchUpAll:
+		return "CatchUpAll"
+	case FireOnce:
+		return "FireOnce"
+	case Skip:
+		return "Skip"
+	}
+	return "MissedTickPolicy(" + strconv.Itoa(int(p)) + ")"
+}
+
+// Ticker is the clock-driven equivalent of time.Ticker. It is created with
+// Clock.NewTicker and must not be copied.
+//
+// By default, C is closed once the ticker is stopped. Tickers of clocks created
+// with WithStdChannels follow the contract of time.Ticker since Go 1.23
+// instead: C is never closed, and no stale tick can be received from it once
+// Stop or Reset has returned.
+type Ticker struct {
+	C <-chan time.Time
+
+	noCopy noCopy
+	run    runner
+	policy MissedTickPolicy
+	std    *time.Ticker // ticker of the time package, for RealClock
+}
+
+// NewTicker returns a new Ticker sending the time on its channel every time
+// the clock goes past a multiple of the period d. It uses the CatchUpAll
+// policy. The period d must be greater than zero; if not, NewTicker panics.
+func (c *Clock) NewTicker(d time.Duration) *Ticker {
+	return c.NewTickerWithPolicy(d, CatchUpAll)
+}
+
+// Tick is a convenience wrapper for NewTicker providing access to the ticking
+// channel only, like time.Tick. Unlike NewTicker, Tick returns nil if d <= 0.
+// The underlying Ticker runs until it is stopped with StopTick, the clock is
+// closed, or its waits are canceled with CancelAll.
+func (c *Clock) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	t := c.NewTicker(d)
+	c.ticksMu.Lock()
+	defer c.ticksMu.Unlock()
+	if c.ticks == nil {
+		c.ticks = make(map[<-chan time.Time]*Ticker)
+	}
+	c.ticks[t.C] = t
+	return t.C
+}
+
+// StopTick stops the Ticker underlying the channel ch returned by Tick, like
+// Ticker.Stop, and releases it. It reports false if ch was not returned by
+// Tick, or its ticker is already released.
+func (c *Clock) StopTick(ch <-chan time.Time) bool {
+	c.ticksMu.Lock()
+	t, ok := c.ticks[ch]
+	delete(c.ticks, ch)
+	c.ticksMu.Unlock()
+	if ok {
+		t.Stop()
+	}
+	return ok
+}
+
+// NewTickerWithPolicy is like NewTicker, but uses the specified policy to
+// handle the ticks missed during large clock advances. If the clock is
+// closed, the ticker is returned already stopped.
+func (c *Clock) NewTickerWithPolicy(d time.Duration, policy MissedTickPolicy) *Ticker {
+	if d <= 0 {
+		panic("crown: non-positive interval for NewTicker")
+	}
+	t := &Ticker{policy: policy}
+	t.start(c, d, c.origin(context.Background(), "ticker"))
+	return t
+}
+
+// start starts the ticker with period d.
+func (t *Ticker) start(c *Clock, d time.Duration, from origin) {
+	ch, ok := t.run.prepare(c)
+	if t.C != ch {
+		t.C = ch
+	}
+	if !ok {
+		return
+	}
+	if inlineTickers {
+		t.schedule(c, ch, c.Now().Add(d), d, from)
+		return
+	}
+	go c.runTicker(t.run.context(), t, ch, c.register(c.Now().Add(d), from), d)
+}
+
+// schedule registers the ticks of t on c in place of a goroutine, each tick
+// registering the next one. Ticks are sent without blocking: those which do
+// not fit in the channel are dropped, whatever the policy of the ticker.
+func (t *Ticker) schedule(c *Clock, ch chan time.Time, next time.Time, d time.Duration, from origin) {
+	var key uint64
+	var stopped int32
+	var fire func(err error)
+	arm := func() {
+		k, ok := c.schedule(&sleepHandler{deadline: next, origin: from, fire: fire})
+		if !ok {
+			return
+		}
+		atomic.StoreUint64(&key, k)
+		// Stop may have run before the key was stored.
+		if atomic.LoadInt32(&stopped) != 0 && c.cancel(k, context.Canceled) {
+			t.run.finish()
+		}
+	}
+	fire = func(err error) {
+		if err != nil {
+			t.run.finish()
+			return
+		}
+		var ticks []time.Time
+		ticks, next = missedTicks(t.policy, next, c.Now(), d)
+		for _, tick := range ticks {
+			select {
+			case ch <- tick:
+			default:
+			}
+		}
+		if atomic.LoadInt32(&stopped) != 0 {
+			t.run.finish()
+			return
+		}
+		arm()
+	}
+	t.run.cancel = func() {
+		atomic.StoreInt32(&stopped, 1)
+		if c.cancel(atomic.LoadUint64(&key), context.Canceled) {
+			t.run.finish()
+		}
+	}
+	arm()
+}
+
+// Stop turns off the ticker. After Stop, no more ticks will be sent and the
+// channel is closed, unless the clock was created with WithStdChannels, in
+// which case a tick not received yet is discarded instead. The ticker is
+// deregistered from its clock before Stop returns. Stop can be called any
+// number of times, concurrently.
+func (t *Ticker) Stop() {
+	if t.std != nil {
+		t.std.Stop()
+		return
+	}
+	t.run.mu.Lock()
+	defer t.run.mu.Unlock()
+	t.run.cancel()
+	// Wait for the goroutine to deregister its wait.
+	<-t.run.done
+	if t.run.clock.stdChannels {
+		t.run.discard()
+	}
+}
+
+// Reset stops the ticker and resets its period to d. The next tick will
+// arrive once the clock has moved by d from its current time. The period d
+// must be greater than zero; if not, Reset panics.
+//
+// If the channel of the ticker has been closed by Stop, Reset gives the
+// ticker a new one, so C must be read again after such a call.
+func (t *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("crown: non-positive interval for Ticker.Reset")
+	}
+	if t.std != nil {
+		t.std.Reset(d)
+		return
+	}
+	t.run.mu.Lock()
+	defer t.run.mu.Unlock()
+	t.run.interrupt()
+	if t.run.clock.stdChannels {
+		t.run.discard()
+	}
+	t.start(t.run.clock, d, t.run.clock.origin(context.Background(), "ticker"))
+}
+
+// runTicker runs the goroutine of the ticker t of period d, which sends its
+// ticks on ch, starting once w is released.
+func (c *Clock) runTicker(ctx context.Context, t *Ticker, ch chan time.Time, w *pendingWait, d time.Duration) {
+	defer t.run.finish()
+	for {
+		handler, err := w.wait(ctx)
+		if err != nil {
+			handler.resume()
+			return
+		}
+		ticks, next := missedTicks(t.policy, w.handler.deadline, c.Now(), d)
+		// Register the next tick before resuming, so that it is visible as
+		// soon as the advance returns.
+		w = c.register(next, w.handler.origin)
+		if !deliverTicks(ctx, ch, ticks, handler) {
+			w.cancel(ctx.Err())
+			return
+		}
+	}
+}
+
+// missedTicks returns the ticks to deliver according to policy when the
+// clock has reached now, and the deadline of the next tick. The deadline
+// next must not be after now.
+func missedTicks(policy MissedTickPolicy, next, now time.Time, d time.Duration) ([]time.Time, time.Time) {
+	n := int(now.Sub(next)/d) + 1
+	switch policy {
+	case FireOnce:
+		return []time.Time{now}, now.Add(d)
+	case Skip:
+		last := next.Add(time.Duration(n-1) * d)
+		return []time.Time{last}, last.Add(d)
+	}
+	ticks := make([]time.Time, n)
+	for i := range ticks {
+		ticks[i] = next.Add(time.Duration(i) * d)
+	}
+	return ticks, next.Add(time.Duration(n) * d)
+}
+
+// deliverTicks sends ticks on ch, blocking until they are received or ctx is
+// done. It reports whether all the ticks have been delivered.
+func deliverTicks(ctx context.Context, ch chan<- time.Time, ticks []time.Time, handler *sleepHandler) bool {
+	if ctx.Err() != nil {
+		handler.resume()
+		return false
+	}
+	// Acknowledge the wake-up once the first tick is buffered, or as soon as
+	// sending it would block.
+	select {
+	case ch <- ticks[0]:
+		ticks = ticks[1:]
+	default:
+	}
+	handler.resume()
+	for _, tick := range ticks {
+		select {
+		case ch <- tick:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
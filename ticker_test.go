@@ -0,0 +1,212 @@
+package crown
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func receiveTick(t *testing.T, clock *Clock, ticker *Ticker) time.Time {
+	t.Helper()
+	select {
+	case tick := <-ticker.C:
+		return tick
+	case <-time.After(time.Second):
+		t.Fatalf("Did not tick after 1 sec. t=%q", clock.Now())
+	}
+	return time.Time{}
+}
+
+func TestTickerMissedTickPolicy(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	at := func(d time.Duration) time.Time { return refT.Add(d) }
+
+	tests := []struct {
+		policy MissedTickPolicy
+		first  []time.Time // ticks after Forward(3.5s)
+		next   time.Time   // tick after Forward(1s) more
+	}{
+		{CatchUpAll, []time.Time{at(1 * time.Second), at(2 * time.Second), at(3 * time.Second)}, at(4 * time.Second)},
+		{FireOnce, []time.Time{at(3500 * time.Millisecond)}, at(4500 * time.Millisecond)},
+		{Skip, []time.Time{at(3 * time.Second)}, at(4 * time.Second)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			if inlineTickers && tt.policy == CatchUpAll {
+				t.Skip("inline tickers drop the ticks which do not fit in the channel")
+			}
+			clock := NewClock(refT)
+			ticker := clock.NewTickerWithPolicy(time.Second, tt.policy)
+			defer ticker.Stop()
+			waitForSleepers(t, clock, 1, 10)
+
+			clock.Forward(3500 * time.Millisecond)
+			for _, want := range tt.first {
+				if got := receiveTick(t, clock, ticker); !got.Equal(want) {
+					t.Errorf("Should tick at %q, got %q instead", want, got)
+				}
+			}
+			waitForSleepers(t, clock, 2, 10)
+			select {
+			case got := <-ticker.C:
+				t.Errorf("Unexpected tick at %q", got)
+			default:
+			}
+
+			clock.Forward(1 * time.Second)
+			if got := receiveTick(t, clock, ticker); !got.Equal(tt.next) {
+				t.Errorf("Should tick at %q, got %q instead", tt.next, got)
+			}
+		})
+	}
+}
+
+func TestTickerStop(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT)
+	ticker := clock.NewTicker(time.Second)
+	waitForSleepers(t, clock, 1, 10)
+
+	ticker.Stop()
+	clock.Forward(2 * time.Second)
+	select {
+	case _, ok := <-ticker.C:
+		if ok {
+			t.Errorf("Ticker has ticked despite Stop() call")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Channel was not closed after 1 sec")
+	}
+}
+
+func TestTickerConcurrentStop(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT)
+	ticker := clock.NewTicker(time.Second)
+
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			ticker.Stop()
+		}()
+	}
+	clock.Forward(time.Second)
+	wg.Wait()
+	ticker.Stop()
+
+	for range ticker.C {
+	}
+}
+
+func TestTickerReset(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+	waitForSleepers(t, clock, 1, 10)
+
+	clock.Forward(500 * time.Millisecond)
+	ticker.Reset(2 * time.Second)
+	waitForSleepers(t, clock, 2, 10)
+	clock.Forward(time.Second)
+	select {
+	case got := <-ticker.C:
+		t.Fatalf("Unexpected tick at %q", got)
+	default:
+	}
+	clock.Forward(time.Second)
+	if got, want := receiveTick(t, clock, ticker), refT.Add(2500*time.Millisecond); got != want {
+		t.Errorf("Should tick at %q, got %q instead", want, got)
+	}
+
+	ticker.Stop()
+	ticker.Reset(time.Second)
+	waitForSleepers(t, clock, 4, 10)
+	clock.Forward(time.Second)
+	if got, want := receiveTick(t, clock, ticker), refT.Add(3500*time.Millisecond); got != want {
+		t.Errorf("Should tick at %q, got %q instead", want, got)
+	}
+}
+
+func TestStdTicker(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT, WithStdChannels(), WithWakeAck())
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for i := 1; i <= 3; i++ {
+		clock.Forward(time.Second)
+		if got, want := receiveTick(t, clock, ticker), refT.Add(time.Duration(i)*time.Second); !got.Equal(want) {
+			t.Errorf("Should tick at %q, got %q instead", want, got)
+		}
+	}
+
+	clock.Forward(time.Second)
+	ticker.Stop()
+	clock.Forward(time.Second)
+	select {
+	case got, ok := <-ticker.C:
+		t.Fatalf("Should neither tick nor close after Stop, got %q, ok=%v", got, ok)
+	default:
+	}
+
+	ticker.Reset(2 * time.Second)
+	clock.Forward(2 * time.Second)
+	if got, want := receiveTick(t, clock, ticker), refT.Add(7*time.Second); !got.Equal(want) {
+		t.Errorf("Should tick at %q after Reset, got %q instead", want, got)
+	}
+}
+
+func TestTick(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+	if clock.Tick(0) != nil {
+		t.Errorf("Tick(0) should return nil")
+	}
+	tick := clock.Tick(time.Second)
+	for i := 1; i <= 2; i++ {
+		clock.Forward(time.Second)
+		if got, want := <-tick, refT.Add(time.Duration(i)*time.Second); !got.Equal(want) {
+			t.Errorf("Should tick at %q, got %q instead", want, got)
+		}
+	}
+	if n := clock.CancelAll(); n != 1 {
+		t.Errorf("CancelAll should stop the ticker, got %d waits", n)
+	}
+	if _, ok := <-tick; ok {
+		t.Errorf("Channel should be closed once the ticker is stopped")
+	}
+}
+
+func TestStopTick(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT)
+	tick := clock.Tick(time.Second)
+	other := clock.Tick(time.Minute)
+	if !clock.StopTick(tick) {
+		t.Errorf("StopTick should stop the ticker of Tick")
+	}
+	if n := clock.Waiters(); n != 1 {
+		t.Errorf("Should release the ticker only, got %d waits", n)
+	}
+	if _, ok := <-tick; ok {
+		t.Errorf("Channel should be closed once the ticker is stopped")
+	}
+	if clock.StopTick(tick) {
+		t.Errorf("StopTick should report false once the ticker is released")
+	}
+	if clock.StopTick(clock.NewTicker(time.Second).C) {
+		t.Errorf("StopTick should report false for the channel of NewTicker")
+	}
+
+	canceled := clock.Tick(time.Second)
+	clock.CancelAll()
+	if clock.StopTick(canceled) || clock.StopTick(other) {
+		t.Errorf("StopTick should report false once the tickers are canceled")
+	}
+	if len(clock.ticks) != 0 {
+		t.Errorf("CancelAll should release the tickers of Tick, got %d", len(clock.ticks))
+	}
+}
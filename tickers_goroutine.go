@@ -0,0 +1,7 @@
+//go:build !js && !wasip1 && !tinygo && !crown_inline
+
+package crown
+
+// inlineTickers reports whether tickers run as callbacks invoked by the clock
+// instead of goroutines. See tickers_inline.go.
+const inlineTickers = false
@@ -0,0 +1,12 @@
+//go:build js || wasip1 || tinygo || crown_inline
+
+package crown
+
+// inlineTickers reports whether tickers run as callbacks invoked by the clock
+// instead of goroutines, like timers always do. This is the case on js/wasm,
+// WASI and TinyGo targets, where goroutines are expensive and may not be
+// preempted, or when building with the crown_inline tag.
+//
+// Inline tickers fire from the goroutine advancing the clock and never block
+// it: a tick which does not fit in the channel of the ticker is dropped.
+const inlineTickers = true
@@ -0,0 +1,30 @@
+//go:build crown_inline
+
+package crown
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestInlineTickersSpawnNoGoroutine(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-10T09:00:00Z")
+	clock := NewClock(refT)
+	before := runtime.NumGoroutine()
+	ticker := clock.NewTicker(time.Second)
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("Should not start goroutines, got %d more", n-before)
+	}
+
+	// The ticker channel is full after its first tick: Forward must not block,
+	// and the other ticks are dropped.
+	clock.Forward(time.Hour)
+	if got, want := <-ticker.C, refT.Add(time.Second); !got.Equal(want) {
+		t.Errorf("Should tick at %q, got %q instead", want, got)
+	}
+	ticker.Stop()
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Should not leave waiters, got %d", n)
+	}
+}
@@ -0,0 +1,90 @@
+package timeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// traceEvent is an event of the Chrome trace-event format.
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Phase string         `json:"ph"`
+	TS    float64        `json:"ts"` // microseconds
+	Dur   float64        `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int64          `json:"tid"`
+	Scope string         `json:"s,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// ChromeTrace writes events to out in the Chrome trace-event JSON format, to
+// be loaded in chrome://tracing or Perfetto. Every goroutine which registered
+// waits gets a track, with a slice per wait, named after its kind and ID, from
+// its registration to its end, or its deadline if it is still pending, and an
+// instant when it fires or is canceled. The goroutines are only known when
+// the clock was created with crown.WithStackCapture: otherwise, every wait
+// gets a track of its own. The advances are instants on the track of the
+// clock.
+// Timestamps are the times of the clock, relative to the first event.
+func ChromeTrace(out io.Writer, events []crown.Event) error {
+	waits, advances := build(events)
+	var origin time.Time
+	for _, e := range events {
+		t := e.Time
+		if e.Kind != crown.EventAdvance && e.Start.Before(t) {
+			t = e.Start
+		}
+		if origin.IsZero() || t.Before(origin) {
+			origin = t
+		}
+	}
+	ts := func(t time.Time) float64 {
+		return float64(t.Sub(origin)) / float64(time.Microsecond)
+	}
+
+	trace := []traceEvent{{
+		Name: "thread_name", Phase: "M", Args: map[string]any{"name": "clock"},
+	}}
+	for _, a := range advances {
+		trace = append(trace, traceEvent{
+			Name: "advance", Phase: "i", TS: ts(a.time), Scope: "g",
+			Args: map[string]any{"by": a.by.String(), "time": a.time.Format(time.RFC3339Nano)},
+		})
+	}
+	tids := make(map[string]int64)
+	for _, w := range waits {
+		track := w.name()
+		if w.goroutine != 0 {
+			track = fmt.Sprintf("goroutine %d", w.goroutine)
+		}
+		tid, ok := tids[track]
+		if !ok {
+			tid = int64(len(tids) + 1)
+			tids[track] = tid
+			trace = append(trace, traceEvent{Name: "thread_name", Phase: "M", TID: tid, Args: map[string]any{"name": track}})
+		}
+		end := w.end
+		if w.outcome == "pending" {
+			end = w.deadline
+		}
+		args := map[string]any{
+			"deadline": w.deadline.Format(time.RFC3339Nano),
+			"outcome":  w.outcome,
+		}
+		trace = append(trace, traceEvent{Name: w.name(), Phase: "X", TS: ts(w.start), Dur: ts(end) - ts(w.start), TID: tid, Args: args})
+		if w.outcome != "pending" {
+			trace = append(trace, traceEvent{Name: w.outcome, Phase: "i", TS: ts(w.end), TID: tid, Scope: "t"})
+		}
+	}
+	for i := range trace {
+		trace[i].PID = 1
+	}
+	return json.NewEncoder(out).Encode(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{trace, "ms"})
+}
@@ -0,0 +1,87 @@
+package timeline
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestChromeTrace(t *testing.T) {
+	var b strings.Builder
+	if err := ChromeTrace(&b, record(t)); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &trace); err != nil {
+		t.Fatalf("Should write JSON, got %v:\n%s", err, b.String())
+	}
+	var slices, instants []string
+	for _, e := range trace.TraceEvents {
+		switch e.Phase {
+		case "X":
+			slices = append(slices, e.Name)
+			if e.Name == "timer 1" && (e.TS != 0 || e.Dur != 2e6) {
+				t.Errorf("timer 1 should last from 0 to 2s, got %+v", e)
+			}
+		case "i":
+			instants = append(instants, e.Name)
+		}
+	}
+	if got, want := strings.Join(slices, ","), "timer 1,timer 2,timer 3"; got != want {
+		t.Errorf("Should have slices %s, got %s", want, got)
+	}
+	if got, want := strings.Join(instants, ","), "advance,fired,canceled"; got != want {
+		t.Errorf("Should have instants %s, got %s", want, got)
+	}
+}
+
+func TestChromeTraceGoroutines(t *testing.T) {
+	log := crown.NewEventLog(32)
+	clock := crown.NewClock(time.Now(), crown.WithStackCapture(), crown.WithEventLog(log))
+	first := clock.NewTimer(time.Second)
+	defer first.Stop()
+	second := clock.NewTimer(time.Minute)
+	defer second.Stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		other := clock.NewTimer(time.Hour)
+		other.Stop()
+	}()
+	<-done
+
+	var b strings.Builder
+	if err := ChromeTrace(&b, log.Events()); err != nil {
+		t.Fatal(err)
+	}
+	var trace struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &trace); err != nil {
+		t.Fatalf("Should write JSON, got %v:\n%s", err, b.String())
+	}
+	tracks := make(map[int64]string)
+	tids := make(map[string]int64)
+	for _, e := range trace.TraceEvents {
+		switch e.Phase {
+		case "M":
+			tracks[e.TID], _ = e.Args["name"].(string)
+		case "X":
+			tids[e.Name] = e.TID
+		}
+	}
+	if len(tracks) != 3 {
+		t.Errorf("Should have the tracks of the clock and of 2 goroutines, got %v", tracks)
+	}
+	if tids["timer 1"] != tids["timer 2"] || tids["timer 1"] == tids["timer 3"] {
+		t.Errorf("Should group the timers by goroutine, got %v", tids)
+	}
+	if name := tracks[tids["timer 1"]]; !strings.HasPrefix(name, "goroutine ") {
+		t.Errorf("Should name the track after the goroutine, got %q", name)
+	}
+}
@@ -0,0 +1,40 @@
+package timeline
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/enzzc/crown"
+)
+
+// JSON writes events to out as a JSON array, one event per line, see
+// crown.Event.MarshalJSON. ReadJSON reads them back, to export them later in
+// another format or to replay them.
+func JSON(out io.Writer, events []crown.Event) error {
+	if _, err := io.WriteString(out, "[\n"); err != nil {
+		return err
+	}
+	for i, e := range events {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if i < len(events)-1 {
+			b = append(b, ',')
+		}
+		if _, err := out.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(out, "]\n")
+	return err
+}
+
+// ReadJSON reads events written by JSON from in.
+func ReadJSON(in io.Reader) ([]crown.Event, error) {
+	var events []crown.Event
+	if err := json.NewDecoder(in).Decode(&events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
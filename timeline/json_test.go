@@ -0,0 +1,56 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestJSON(t *testing.T) {
+	events := record(t)
+	var b strings.Builder
+	if err := JSON(&b, events); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `{"kind":"advance","time":"2022-12-19T09:00:02Z","advance":2000000000}`) {
+		t.Errorf("Should encode the advance, got:\n%s", b.String())
+	}
+	got, err := ReadJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("Should read back\n%+v\ngot\n%+v", events, got)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-19T09:00:00Z")
+	log := crown.NewEventLog(0)
+	clock := crown.NewClock(refT, crown.WithEventLog(log))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		clock.SleepWithContext(ctx, time.Hour)
+	}()
+	clock.BlockUntil(1)
+	cancel()
+	<-done
+	var b strings.Builder
+	if err := JSON(&b, log.Events()); err != nil {
+		t.Fatal(err)
+	}
+	events, err := ReadJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].Kind != crown.EventCancel || !errors.Is(events[1].Err, context.Canceled) {
+		t.Errorf("Should decode the cause of the cancellation, got %+v", events)
+	}
+}
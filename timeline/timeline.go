@@ -0,0 +1,148 @@
+// Package timeline exports the events recorded from a crown clock, typically
+// by a crown.EventLog, as diagrams showing what happened when: Mermaid gantt
+// charts and Graphviz graphs, to be attached to bug reports, Chrome traces to
+// inspect big simulations, and JSON to be processed by other tools.
+package timeline
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+// wait is the lifetime of a wait, from its registration to its end.
+type wait struct {
+	id        int64
+	kind      string
+	goroutine uint64 // goroutine which registered the wait, or 0
+	start     time.Time
+	deadline  time.Time
+	end       time.Time // zero while pending
+	outcome   string    // fired, canceled or pending
+	cause     int       // index of the advance which fired the wait, or -1
+	after     int       // index of the last advance before its registration, or -1
+}
+
+type advance struct {
+	time time.Time
+	by   time.Duration
+}
+
+// build rebuilds the waits and advances described by events, in order.
+func build(events []crown.Event) ([]*wait, []advance) {
+	var waits []*wait
+	var advances []advance
+	byID := make(map[int64]*wait)
+	get := func(e crown.Event) *wait {
+		w := byID[e.ID]
+		if w == nil {
+			// The registration may have been dropped from the log.
+			w = &wait{
+				id:        e.ID,
+				kind:      e.Wait,
+				goroutine: e.Goroutine,
+				start:     e.Start,
+				deadline:  e.Deadline,
+				outcome:   "pending",
+				cause:     -1,
+				after:     len(advances) - 1,
+			}
+			byID[e.ID] = w
+			waits = append(waits, w)
+		}
+		return w
+	}
+	for _, e := range events {
+		switch e.Kind {
+		case crown.EventAdvance:
+			advances = append(advances, advance{time: e.Time, by: e.Advance})
+		case crown.EventRegister:
+			get(e)
+		case crown.EventFire:
+			w := get(e)
+			w.end, w.outcome, w.cause = e.Time, "fired", len(advances)-1
+		case crown.EventCancel:
+			w := get(e)
+			w.end, w.outcome = e.Time, "canceled"
+		}
+	}
+	return waits, advances
+}
+
+func (w *wait) name() string {
+	kind := w.kind
+	if kind == "" {
+		kind = "wait"
+	}
+	return fmt.Sprintf("%s %d", kind, w.id)
+}
+
+const mermaidTime = "2006-01-02T15:04:05.000"
+
+// Mermaid writes events to out as a Mermaid gantt chart, with a bar per wait,
+// from its registration to its end, or its deadline if it is still pending,
+// and a milestone per advance. Times are written in UTC.
+func Mermaid(out io.Writer, events []crown.Event) error {
+	waits, advances := build(events)
+	b := bufio.NewWriter(out)
+	fmt.Fprintln(b, "gantt")
+	fmt.Fprintln(b, "    title crown timeline")
+	fmt.Fprintln(b, "    dateFormat YYYY-MM-DDTHH:mm:ss.SSS")
+	fmt.Fprintln(b, "    axisFormat %H:%M:%S")
+	fmt.Fprintln(b, "    section waits")
+	for _, w := range waits {
+		tag, end := "done", w.end
+		switch w.outcome {
+		case "canceled":
+			tag = "crit"
+		case "pending":
+			tag, end = "active", w.deadline
+		}
+		fmt.Fprintf(b, "    %s (%s) :%s, w%d, %s, %s\n", w.name(), w.outcome, tag, w.id,
+			w.start.UTC().Format(mermaidTime), end.UTC().Format(mermaidTime))
+	}
+	if len(advances) > 0 {
+		fmt.Fprintln(b, "    section advances")
+	}
+	for i, a := range advances {
+		at := a.time.UTC().Format(mermaidTime)
+		fmt.Fprintf(b, "    +%s :milestone, a%d, %s, %s\n", a.by, i, at, at)
+	}
+	return b.Flush()
+}
+
+// Graphviz writes events to out as a Graphviz digraph: the advances are
+// chained in order, and each wait is linked to the advance preceding its
+// registration and, if it fired, to the advance which fired it.
+func Graphviz(out io.Writer, events []crown.Event) error {
+	waits, advances := build(events)
+	b := bufio.NewWriter(out)
+	fmt.Fprintln(b, "digraph crown {")
+	fmt.Fprintln(b, "  rankdir=LR;")
+	fmt.Fprintln(b, `  start [shape=point];`)
+	for i, a := range advances {
+		fmt.Fprintf(b, "  a%d [shape=box, label=%q];\n", i, fmt.Sprintf("%s\n+%s", a.time.Format(time.RFC3339Nano), a.by))
+		fmt.Fprintf(b, "  %s -> a%d [style=bold];\n", advanceNode(i-1), i)
+	}
+	for _, w := range waits {
+		color := map[string]string{"fired": "darkgreen", "canceled": "red", "pending": "orange"}[w.outcome]
+		label := fmt.Sprintf("%s\nuntil %s\n%s", w.name(), w.deadline.Format(time.RFC3339Nano), w.outcome)
+		fmt.Fprintf(b, "  w%d [shape=ellipse, color=%s, label=%q];\n", w.id, color, label)
+		fmt.Fprintf(b, "  %s -> w%d [style=dashed, label=\"registered\"];\n", advanceNode(w.after), w.id)
+		if w.cause >= 0 {
+			fmt.Fprintf(b, "  a%d -> w%d [label=\"fired\"];\n", w.cause, w.id)
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+func advanceNode(i int) string {
+	if i < 0 {
+		return "start"
+	}
+	return fmt.Sprintf("a%d", i)
+}
@@ -0,0 +1,66 @@
+package timeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func record(t *testing.T) []crown.Event {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-19T09:00:00Z")
+	log := crown.NewEventLog(32)
+	clock := crown.NewClock(refT, crown.WithWakeAck(), crown.WithEventLog(log))
+	fired := clock.NewTimer(time.Second)
+	canceled := clock.NewTimer(time.Minute)
+	clock.Forward(2 * time.Second)
+	<-fired.C
+	canceled.Stop()
+	for clock.Waiters() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	pending := clock.NewTimer(time.Hour)
+	defer pending.Stop()
+	return log.Events()
+}
+
+func TestMermaid(t *testing.T) {
+	var b strings.Builder
+	if err := Mermaid(&b, record(t)); err != nil {
+		t.Fatal(err)
+	}
+	want := `gantt
+    title crown timeline
+    dateFormat YYYY-MM-DDTHH:mm:ss.SSS
+    axisFormat %H:%M:%S
+    section waits
+    timer 1 (fired) :done, w1, 2022-12-19T09:00:00.000, 2022-12-19T09:00:02.000
+    timer 2 (canceled) :crit, w2, 2022-12-19T09:00:00.000, 2022-12-19T09:00:02.000
+    timer 3 (pending) :active, w3, 2022-12-19T09:00:02.000, 2022-12-19T10:00:02.000
+    section advances
+    +2s :milestone, a0, 2022-12-19T09:00:02.000, 2022-12-19T09:00:02.000
+`
+	if got := b.String(); got != want {
+		t.Errorf("Should write:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestGraphviz(t *testing.T) {
+	var b strings.Builder
+	if err := Graphviz(&b, record(t)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"start -> a0 [style=bold];",
+		`start -> w1 [style=dashed, label="registered"];`,
+		`a0 -> w1 [label="fired"];`,
+		`w2 [shape=ellipse, color=red,`,
+		`a0 -> w3 [style=dashed, label="registered"];`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Graph should contain %q, got:\n%s", want, got)
+		}
+	}
+}
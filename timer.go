@@ -0,0 +1,335 @@
+package crown
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timer is the clock-driven equivalent of time.Timer. It is created with
+// Clock.NewTimer and must not be copied.
+//
+// By default, C is buffered and neither Stop nor Reset drain it, like with
+// time.Timer before Go 1.23, and C is closed once the timer is done. Timers of
+// clocks created with WithStdChannels follow the contract of time.Timer since
+// Go 1.23 instead: C is never closed, and no stale value can be received from
+// it once Stop or Reset has returned. In both cases, a timer which is neither
+// fired nor stopped stays referenced by its clock.
+type Timer struct {
+	C <-chan time.Time
+
+	noCopy noCopy
+	run    runner
+	state  int32
+	fn     func()      // function of an AfterFunc timer, nil otherwise
+	std    *time.Timer // timer of the time package, for RealClock
+}
+
+// TimerState describes the state of a Timer.
+type TimerState int32
+
+const (
+	// TimerPending means that the timer has neither fired nor been stopped.
+	TimerPending TimerState = iota
+	// TimerFired means that the timer has fired.
+	TimerFired
+	// TimerStopped means that the timer has been stopped before firing.
+	TimerStopped
+)
+
+func (s TimerState) String() string {
+	switch s {
+	case TimerPending:
+		return "pending"
+	case TimerFired:
+		return "fired"
+	case TimerStopped:
+		return "stopped"
+	}
+	return "TimerState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// NewTimer creates a new clock-associated Timer that will send the current
+// time on its channel after at least duration d. The timer is registered on
+// the clock before NewTimer returns, so that an immediate Forward fires it. If
+// the clock is closed, the timer is returned already stopped.
+//
+// Unless the clock was created with WithStdChannels, the channel of the timer
+// is closed once the timer has fired or been stopped.
+func (c *Clock) NewTimer(d time.Duration) *Timer {
+	t := &Timer{}
+	t.start(c, d, c.origin(context.Background(), "timer"))
+	return t
+}
+
+// After waits for the clock to move by duration d and then sends the current
+// time on the returned channel, like time.After. It is equivalent to
+// NewTimer(d).C. The underlying Timer is not recovered until it fires.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C
+}
+
+// AfterFunc waits for the clock to move by duration d and then calls f in its
+// own goroutine, like time.AfterFunc. It returns a Timer that can be used to
+// cancel the call using its Stop method, or to reschedule it with Reset. The
+// channel C of the timer is nil. Clock.WaitFuncs waits for the calls started
+// by an advance to return.
+func (c *Clock) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{fn: f}
+	t.start(c, d, c.origin(context.Background(), "timer"))
+	return t
+}
+
+// start arms the timer to fire after duration d.
+func (t *Timer) start(c *Clock, d time.Duration, from origin) {
+	ch, ok := t.run.prepare(c)
+	if t.fn == nil && t.C != ch {
+		t.C = ch
+	}
+	if !ok {
+		atomic.StoreInt32(&t.state, int32(TimerStopped))
+		return
+	}
+	atomic.StoreInt32(&t.state, int32(TimerPending))
+	deadline := c.Now().Add(d + c.timerJitter())
+	if t.fn != nil {
+		// The function is started by the advance which fires the timer,
+		// so that WaitFuncs can join it as soon as the advance returns.
+		t.run.schedule(deadline, from, func(err error) {
+			if t.fired(err) {
+				c.funcs.start(t.fn)
+			}
+		})
+		return
+	}
+	// No goroutine backs the timer: the advance which releases it sends on
+	// the channel, without blocking, so a value still pending is kept.
+	t.run.schedule(deadline, from, func(err error) {
+		if t.fired(err) {
+			select {
+			case ch <- c.Now():
+			default:
+			}
+		}
+	})
+}
+
+// WaitFuncs waits for the functions of AfterFunc timers currently running to
+// return. Functions are started by the advance firing their timer, so that
+// WaitFuncs called after Forward joins them.
+func (c *Clock) WaitFuncs() {
+	c.funcs.wait()
+}
+
+// funcGroup tracks the running functions of AfterFunc timers. Its zero value
+// is ready to use.
+type funcGroup struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // closed once n drops to zero, nil until waited for
+}
+
+// start runs f in a new goroutine.
+func (g *funcGroup) start(f func()) {
+	g.mu.Lock()
+	g.n++
+	g.mu.Unlock()
+	go func() {
+		defer g.done()
+		f()
+	}()
+}
+
+func (g *funcGroup) done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.n--
+	if g.n == 0 && g.idle != nil {
+		close(g.idle)
+		g.idle = nil
+	}
+}
+
+func (g *funcGroup) wait() {
+	g.mu.Lock()
+	if g.n == 0 {
+		g.mu.Unlock()
+		return
+	}
+	if g.idle == nil {
+		g.idle = make(chan struct{})
+	}
+	idle := g.idle
+	g.mu.Unlock()
+	<-idle
+}
+
+// fired records the end of the wait of the timer, interrupted unless err is
+// nil, and reports whether the timer must send on its channel.
+func (t *Timer) fired(err error) bool {
+	if err != nil {
+		atomic.CompareAndSwapInt32(&t.state, int32(TimerPending), int32(TimerStopped))
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&t.state, int32(TimerPending), int32(TimerFired))
+}
+
+// Stop prevents the Timer from firing. It returns true if the call stops the
+// timer, false if the timer has already fired or been stopped. The timer is
+// deregistered from its clock before Stop returns. Stop can be called any
+// number of times, concurrently, in any state.
+//
+// By default, Stop does not drain the channel: after a Stop returning false, a
+// value may be pending on C, hence the idiom:
+//
+//	if !t.Stop() {
+//		<-t.C
+//	}
+func (t *Timer) Stop() bool {
+	t.run.mu.Lock()
+	defer t.run.mu.Unlock()
+	return t.stop()
+}
+
+func (t *Timer) stop() bool {
+	if t.std != nil {
+		return t.stopStd()
+	}
+	if atomic.CompareAndSwapInt32(&t.state, int32(TimerPending), int32(TimerStopped)) {
+		t.run.cancel()
+		return true
+	}
+	if !t.run.clock.stdChannels {
+		return false
+	}
+	// A fired timer whose value has not been received yet is stopped.
+	<-t.run.done
+	if !t.run.discard() {
+		return false
+	}
+	atomic.StoreInt32(&t.state, int32(TimerStopped))
+	return true
+}
+
+// Reset changes the timer to expire after duration d, counted from the
+// current time of its clock. It returns true if the timer had been active,
+// false if the timer had fired or been stopped, with the same meaning as
+// Stop. By default, Reset should be invoked only on stopped or fired timers
+// with drained channels, like with time.Timer before Go 1.23.
+//
+// If the channel of the timer has been closed, Reset gives the timer a new
+// one, so C must be read again after such a call.
+func (t *Timer) Reset(d time.Duration) bool {
+	t.run.mu.Lock()
+	defer t.run.mu.Unlock()
+	if t.std == nil {
+		// Keep the channel open while the pending wait is canceled, so
+		// that the receivers of an active timer keep waiting on it.
+		atomic.StoreInt32(&t.run.reset, 1)
+	}
+	active := t.stop()
+	if t.std != nil {
+		atomic.StoreInt32(&t.state, int32(TimerPending))
+		t.std.Reset(d)
+		return active
+	}
+	t.run.interrupt()
+	t.start(t.run.clock, d, t.run.clock.origin(context.Background(), "timer"))
+	return active
+}
+
+// State returns the current state of the timer. It does not consume the
+// timer's channel.
+func (t *Timer) State() TimerState {
+	return TimerState(atomic.LoadInt32(&t.state))
+}
+
+// runner manages the goroutine backing a Ticker, or the callback registered
+// on the clock by a Timer or an inline ticker, and its channel. A callback is
+// considered running until it has been called.
+type runner struct {
+	mu     sync.Mutex // serializes Stop and Reset
+	clock  *Clock
+	ch     chan time.Time
+	closed bool // whether ch was closed by the goroutine
+	cancel func()
+	done   chan struct{} // closed once the goroutine has returned
+	reset  int32         // set to keep ch open while the goroutine is interrupted
+}
+
+// prepare readies a new goroutine on clock c. It returns the channel the
+// goroutine must send on, which is a new one unless the current one is still
+// open. It reports false, without readying anything, if the clock is closed.
+func (r *runner) prepare(c *Clock) (chan time.Time, bool) {
+	r.clock = c
+	if r.ch == nil || r.closed {
+		r.ch = make(chan time.Time, 1)
+		r.closed = false
+	}
+	r.cancel = func() {}
+	r.done = make(chan struct{})
+	atomic.StoreInt32(&r.reset, 0)
+	if c.isClosed() {
+		r.finish()
+		return r.ch, false
+	}
+	return r.ch, true
+}
+
+// context returns the context of the goroutine, done once it is canceled.
+func (r *runner) context() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	return ctx
+}
+
+// schedule registers fire on the clock in place of the goroutine, to be
+// called at deadline, or with an error if the wait is interrupted.
+func (r *runner) schedule(deadline time.Time, from origin, fire func(err error)) {
+	c := r.clock
+	key, ok := c.schedule(&sleepHandler{
+		deadline: deadline,
+		origin:   from,
+		fire: func(err error) {
+			fire(err)
+			r.finish()
+		},
+	})
+	r.cancel = func() {
+		if ok && c.cancel(key, context.Canceled) {
+			r.finish()
+		}
+	}
+}
+
+// finish must be called by the goroutine when it returns. It closes the
+// channel, unless the goroutine was interrupted by interrupt or the clock
+// mimics the time package, where channels stay open.
+func (r *runner) finish() {
+	if atomic.LoadInt32(&r.reset) == 0 && !r.clock.stdChannels {
+		close(r.ch)
+		r.closed = true
+	}
+	close(r.done)
+}
+
+// interrupt stops the goroutine, keeping its channel open, and waits for it
+// to return.
+func (r *runner) interrupt() {
+	atomic.StoreInt32(&r.reset, 1)
+	r.cancel()
+	<-r.done
+}
+
+// discard drops the value pending on the channel, if any, and reports whether
+// there was one.
+func (r *runner) discard() bool {
+	select {
+	case <-r.ch:
+		return true
+	default:
+		return false
+	}
+}
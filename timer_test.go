@@ -0,0 +1,293 @@
+package crown
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimerChannelCapacity(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+	timer := clock.NewTimer(time.Second)
+	defer timer.Stop()
+	if got := cap(timer.C); got != 1 {
+		t.Errorf("Channel capacity should be 1, got %d instead", got)
+	}
+}
+
+func TestNewTimerRegistersSynchronously(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	for i := 0; i < 100; i++ {
+		clock := NewClock(refT)
+		timer := clock.NewTimer(time.Second)
+		ticker := clock.NewTicker(time.Second)
+		if got := clock.Waiters(); got != 2 {
+			t.Fatalf("Should register 2 waiters before returning, got %d", got)
+		}
+		clock.Forward(time.Second)
+		select {
+		case <-timer.C:
+		case <-time.After(time.Second):
+			t.Fatalf("Timer missed an immediate Forward")
+		}
+		receiveTick(t, clock, ticker)
+		ticker.Stop()
+	}
+}
+
+func TestTimersSpawnNoGoroutine(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+	before := runtime.NumGoroutine()
+	timers := make([]*Timer, 1000)
+	for i := range timers {
+		timers[i] = clock.NewTimer(time.Duration(i+1) * time.Second)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("Should not start goroutines, got %d more", n-before)
+	}
+	if !timers[0].Stop() {
+		t.Errorf("Stop() on a pending timer should return true")
+	}
+	if n := clock.Waiters(); n != len(timers)-1 {
+		t.Errorf("Stop() should deregister the timer at once, got %d waiters", n)
+	}
+
+	clock.Forward(time.Hour)
+	for i, timer := range timers[1:] {
+		if got := timer.State(); got != TimerFired {
+			t.Errorf("Timer %d should have fired, got %v", i+1, got)
+		}
+		if got, want := <-timer.C, refT.Add(time.Hour); !got.Equal(want) {
+			t.Errorf("Timer %d should fire at %q, got %q instead", i+1, want, got)
+		}
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("Should not leave waiters, got %d", n)
+	}
+}
+
+func TestTimerReset(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck())
+	timer := clock.NewTimer(time.Minute)
+	waitForSleepers(t, clock, 1, 10)
+
+	// Reset of an active timer.
+	if !timer.Reset(2 * time.Minute) {
+		t.Errorf("Reset() on a pending timer should return true")
+	}
+	waitForSleepers(t, clock, 2, 10)
+	clock.Forward(time.Minute)
+	select {
+	case <-timer.C:
+		t.Fatalf("Timer fired at its former deadline")
+	default:
+	}
+	clock.Forward(time.Minute)
+	select {
+	case got := <-timer.C:
+		if want := refT.Add(2 * time.Minute); got != want {
+			t.Errorf("Should fire at %q, got %q instead", want, got)
+		}
+	default:
+		t.Fatalf("Timer did not fire at its new deadline")
+	}
+
+	// Reset of a fired timer, relative to the current time.
+	if timer.Reset(time.Minute) {
+		t.Errorf("Reset() on a fired timer should return false")
+	}
+	if got := timer.State(); got != TimerPending {
+		t.Errorf("Should be %v, got %v instead", TimerPending, got)
+	}
+	waitForSleepers(t, clock, 3, 10)
+	clock.Forward(time.Minute)
+	if got, want := <-timer.C, refT.Add(3*time.Minute); got != want {
+		t.Errorf("Should fire at %q, got %q instead", want, got)
+	}
+
+	// Reset of a stopped timer.
+	timer.Reset(time.Minute)
+	timer.Stop()
+	if timer.Reset(time.Minute) {
+		t.Errorf("Reset() on a stopped timer should return false")
+	}
+	timer.Stop()
+}
+
+func TestTimerResetKeepsChannel(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT)
+	timer := clock.NewTimer(time.Minute)
+	ch := timer.C
+	received := make(chan bool)
+	go func() {
+		_, ok := <-ch
+		received <- ok
+	}()
+	if !timer.Reset(time.Hour) {
+		t.Errorf("Reset() on a pending timer should return true")
+	}
+	if timer.C != ch {
+		t.Errorf("Reset() of an active timer should keep its channel")
+	}
+	select {
+	case <-received:
+		t.Fatalf("Reset() should not close the channel of an active timer")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Forward(time.Hour)
+	if ok := <-received; !ok {
+		t.Errorf("Receiver should get the value of the reset timer")
+	}
+}
+
+func TestStdTimerReset(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck(), WithStdChannels())
+	timer := clock.NewTimer(time.Second)
+	clock.Forward(time.Second)
+
+	// A value not received yet is discarded, and the timer counts as active.
+	if !timer.Reset(time.Second) {
+		t.Errorf("Reset() should return true while the value is not received")
+	}
+	select {
+	case got := <-timer.C:
+		t.Fatalf("Received a stale value %q after Reset()", got)
+	default:
+	}
+	clock.Forward(time.Second)
+	if got, want := <-timer.C, refT.Add(2*time.Second); got != want {
+		t.Errorf("Should fire at %q, got %q instead", want, got)
+	}
+
+	// A non-positive duration fires right away.
+	if timer.Reset(-time.Second) {
+		t.Errorf("Reset() on a received timer should return false")
+	}
+	select {
+	case got := <-timer.C:
+		if want := refT.Add(2 * time.Second); got != want {
+			t.Errorf("Should fire at %q, got %q instead", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Timer reset with a negative duration did not fire")
+	}
+}
+
+func TestTimerStopDrainIdiom(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	for _, std := range []bool{false, true} {
+		opts := []Option{WithWakeAck()}
+		if std {
+			opts = append(opts, WithStdChannels())
+		}
+		clock := NewClock(refT, opts...)
+		timer := clock.NewTimer(time.Second)
+		clock.Forward(time.Second)
+
+		stopped := timer.Stop()
+		if stopped != std {
+			t.Errorf("Stop() on a fired timer should return %v with std channels %v, got %v", std, std, stopped)
+		}
+		if !stopped {
+			// The value of the fired timer is left on the channel.
+			select {
+			case <-timer.C:
+			default:
+				t.Fatalf("Stop() should leave the value of a fired timer")
+			}
+		}
+		timer.Reset(time.Second)
+		clock.Forward(time.Second)
+		if got, want := <-timer.C, refT.Add(2*time.Second); got != want {
+			t.Errorf("Should fire at %q, got %q instead", want, got)
+		}
+	}
+}
+
+func TestStdTimerStopDiscardsValue(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := NewClock(refT, WithWakeAck(), WithStdChannels())
+	timer := clock.NewTimer(time.Second)
+	waitForSleepers(t, clock, 1, 10)
+	clock.Forward(time.Second)
+
+	if !timer.Stop() {
+		t.Errorf("Stop() should return true while the value is not received")
+	}
+	select {
+	case got := <-timer.C:
+		t.Errorf("Received a stale value %q after Stop()", got)
+	default:
+	}
+	if timer.Stop() {
+		t.Errorf("Stop() on a stopped timer should return false")
+	}
+}
+
+func TestAfter(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-31T09:00:00Z")
+	clock := NewClock(refT)
+	ch := clock.After(time.Second)
+	select {
+	case got := <-ch:
+		t.Fatalf("Unexpected value %q before the deadline", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Forward(time.Second)
+	select {
+	case got := <-ch:
+		if want := refT.Add(time.Second); !got.Equal(want) {
+			t.Errorf("Should receive %q, got %q instead", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Did not fire after 1 sec")
+	}
+}
+
+func TestAfterFunc(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-31T09:00:00Z")
+	clock := NewClock(refT)
+	var calls int32
+	f := func() {
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&calls, 1)
+	}
+	fired := clock.AfterFunc(time.Second, f)
+	stopped := clock.AfterFunc(time.Second, f)
+	if fired.C != nil {
+		t.Errorf("Channel of AfterFunc timer should be nil")
+	}
+	if clock.Waiters() != 2 {
+		t.Errorf("Should register 2 waiters, got %d", clock.Waiters())
+	}
+	if !stopped.Stop() {
+		t.Errorf("Stop should prevent the call")
+	}
+
+	clock.Forward(time.Second)
+	clock.WaitFuncs()
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Should call the function once, got %d", got)
+	}
+	if fired.Stop() {
+		t.Errorf("Stop should return false once the function is started")
+	}
+
+	if fired.Reset(time.Second) {
+		t.Errorf("Reset should return false once the function is started")
+	}
+	clock.Forward(time.Second)
+	clock.WaitFuncs()
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Should call the function again after Reset, got %d calls", got)
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Should have no waiter left, got %d", clock.Waiters())
+	}
+}
@@ -0,0 +1,139 @@
+// Package timeutil mirrors the functions of the time package which depend on
+// the current time, and binds them to a configurable crown Clock. Code using
+// them can be migrated mechanically, by replacing calls such as time.Now or
+// time.After with timeutil.Now or timeutil.After, then controlled in tests
+// with SetClock. As long as no clock is set, the functions of the package
+// behave exactly like their time counterparts.
+package timeutil
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+var current atomic.Value // *crown.Clock
+
+// SetClock makes the functions of the package use clock c, or the real time if
+// c is nil. It returns a function restoring the previous clock, which suits
+// defer statements and testing.T.Cleanup.
+func SetClock(c *crown.Clock) (restore func()) {
+	prev := clock()
+	current.Store(c)
+	return func() {
+		current.Store(prev)
+	}
+}
+
+func clock() *crown.Clock {
+	c, _ := current.Load().(*crown.Clock)
+	return c
+}
+
+// Now is the equivalent of time.Now.
+func Now() time.Time {
+	if c := clock(); c != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+// Since is the equivalent of time.Since.
+func Since(t time.Time) time.Duration {
+	return Now().Sub(t)
+}
+
+// Until is the equivalent of time.Until.
+func Until(t time.Time) time.Duration {
+	return t.Sub(Now())
+}
+
+// Sleep is the equivalent of time.Sleep.
+func Sleep(d time.Duration) {
+	if c := clock(); c != nil {
+		c.Sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+// After is the equivalent of time.After.
+func After(d time.Duration) <-chan time.Time {
+	return NewTimer(d).C
+}
+
+// Timer is the equivalent of time.Timer.
+type Timer struct {
+	C <-chan time.Time
+
+	std  *time.Timer
+	fake *crown.Timer
+}
+
+// NewTimer is the equivalent of time.NewTimer.
+func NewTimer(d time.Duration) *Timer {
+	if c := clock(); c != nil {
+		t := c.NewTimer(d)
+		return &Timer{C: t.C, fake: t}
+	}
+	t := time.NewTimer(d)
+	return &Timer{C: t.C, std: t}
+}
+
+// Stop is the equivalent of time.Timer.Stop.
+func (t *Timer) Stop() bool {
+	if t.fake != nil {
+		return t.fake.Stop()
+	}
+	return t.std.Stop()
+}
+
+// Reset is the equivalent of time.Timer.Reset. Timers backed by a crown clock
+// may get a new channel when reset once closed, so C must be read again after
+// Reset.
+func (t *Timer) Reset(d time.Duration) bool {
+	if t.fake != nil {
+		active := t.fake.Reset(d)
+		t.C = t.fake.C
+		return active
+	}
+	return t.std.Reset(d)
+}
+
+// Ticker is the equivalent of time.Ticker.
+type Ticker struct {
+	C <-chan time.Time
+
+	std  *time.Ticker
+	fake *crown.Ticker
+}
+
+// NewTicker is the equivalent of time.NewTicker.
+func NewTicker(d time.Duration) *Ticker {
+	if c := clock(); c != nil {
+		t := c.NewTicker(d)
+		return &Ticker{C: t.C, fake: t}
+	}
+	t := time.NewTicker(d)
+	return &Ticker{C: t.C, std: t}
+}
+
+// Stop is the equivalent of time.Ticker.Stop.
+func (t *Ticker) Stop() {
+	if t.fake != nil {
+		t.fake.Stop()
+		return
+	}
+	t.std.Stop()
+}
+
+// Reset is the equivalent of time.Ticker.Reset.
+func (t *Ticker) Reset(d time.Duration) {
+	if t.fake != nil {
+		t.fake.Reset(d)
+		t.C = t.fake.C
+		return
+	}
+	t.std.Reset(d)
+}
@@ -0,0 +1,53 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/enzzc/crown"
+)
+
+func TestRealTime(t *testing.T) {
+	before := time.Now()
+	now := Now()
+	if now.Before(before) || Since(before) < 0 {
+		t.Errorf("Should follow the real time, got %q (started at %q)", now, before)
+	}
+	select {
+	case <-After(time.Millisecond):
+	case <-time.After(time.Second):
+		t.Errorf("After did not fire after 1 sec")
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-06T09:00:00Z")
+	clock := crown.NewClock(refT, crown.WithWakeAck())
+	restore := SetClock(clock)
+	defer restore()
+
+	if got := Now(); got != refT {
+		t.Errorf("Should be %q, got %q instead", refT, got)
+	}
+	after := After(time.Minute)
+	for clock.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Forward(time.Minute)
+	select {
+	case <-after:
+	default:
+		t.Errorf("After did not fire at t+1m. t=%q", Now())
+	}
+	if got := Since(refT); got != time.Minute {
+		t.Errorf("Should be %v, got %v instead", time.Minute, got)
+	}
+	if got := Until(refT); got != -time.Minute {
+		t.Errorf("Should be %v, got %v instead", -time.Minute, got)
+	}
+
+	restore()
+	if got := Now(); got.Equal(refT.Add(time.Minute)) {
+		t.Errorf("Should follow the real time again once restored")
+	}
+}
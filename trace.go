@@ -0,0 +1,42 @@
+package crown
+
+import "context"
+
+// origin identifies where a wait comes from.
+type origin struct {
+	kind  string    // what waits: sleep, timer or ticker
+	stack []uintptr // call stack which registered the wait, if captured
+	goid  uint64    // goroutine which registered the wait, if captured
+	trace string    // trace identifier of the wait, if any
+}
+
+// origin returns the origin of a wait of the given kind registered now, in
+// the context ctx.
+func (c *Clock) origin(ctx context.Context, kind string) origin {
+	if c.strict != nil {
+		c.checkExpected(kind)
+	}
+	from := origin{kind: kind, stack: c.callers()}
+	if from.stack != nil {
+		from.goid = goroutineID()
+	}
+	if c.tracer != nil {
+		from.trace = c.tracer(ctx)
+	}
+	return from
+}
+
+// WithTraceContext makes the clock call fn when a wait is registered, to
+// record a trace identifier, such as the IDs of the current span, along with
+// the wait. The identifier is then reported by the events of the wait and by
+// Pending, so that tooling can correlate a fire with the code which created
+// the waiting timer.
+//
+// The context passed to fn is the one of SleepWithContext. Timers and tickers
+// are not bound to a context, so fn is passed context.Background for them:
+// fn can then report an identifier found elsewhere, or the empty string.
+func WithTraceContext(fn func(ctx context.Context) string) Option {
+	return func(c *Clock) {
+		c.tracer = fn
+	}
+}
@@ -0,0 +1,45 @@
+package crown
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+func TestTraceContext(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2022-12-16T09:00:00Z")
+	log := NewEventLog(16)
+	clock := NewClock(refT, WithEventLog(log), WithTraceContext(func(ctx context.Context) string {
+		id, _ := ctx.Value(traceKey{}).(string)
+		return id
+	}))
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "span-1")
+	done := make(chan error)
+	go func() {
+		done <- clock.SleepWithContext(ctx, time.Second)
+	}()
+	for clock.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	pending := clock.Pending()
+	if pending[0].Trace != "span-1" {
+		t.Errorf("Pending wait should have trace %q, got %q", "span-1", pending[0].Trace)
+	}
+	clock.Forward(time.Second)
+	<-done
+
+	fires := log.Query(Kinds(EventFire))
+	if len(fires) != 1 {
+		t.Fatalf("Should record 1 fire, got %v", fires)
+	}
+	if fires[0].Trace != "span-1" || fires[0].ID != pending[0].ID {
+		t.Errorf("Fire should carry the trace %q and ID %d, got %+v", "span-1", pending[0].ID, fires[0])
+	}
+	registers := log.Query(Kinds(EventRegister))
+	if len(registers) != 1 || registers[0].ID != fires[0].ID {
+		t.Errorf("Registration and fire should share their ID, got %v", registers)
+	}
+}
@@ -0,0 +1,78 @@
+package crown
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// UncertainClock exposes the time of a clock as an interval bounding the true
+// time, like the TrueTime API of Spanner: the clock it embeds reads the true
+// time, and the interval spreads on either side of it by the uncertainty
+// bound ε. It lets commit-wait protocols be prototyped under simulated time.
+// An UncertainClock is created with NewUncertainClock, and is safe for
+// concurrent use.
+type UncertainClock struct {
+	epsilon int64 // time.Duration, first to be 64-bit aligned
+	*Clock
+}
+
+// NewUncertainClock returns a clock reading the time of c with the
+// uncertainty bound epsilon. It panics if epsilon is negative.
+func NewUncertainClock(c *Clock, epsilon time.Duration) *UncertainClock {
+	u := &UncertainClock{Clock: c}
+	u.SetEpsilon(epsilon)
+	return u
+}
+
+// Epsilon returns the uncertainty bound of the clock.
+func (u *UncertainClock) Epsilon() time.Duration {
+	return time.Duration(atomic.LoadInt64(&u.epsilon))
+}
+
+// SetEpsilon changes the uncertainty bound of the clock, such as to simulate
+// the growing uncertainty of a node losing its time reference. It panics if
+// epsilon is negative.
+func (u *UncertainClock) SetEpsilon(epsilon time.Duration) {
+	if epsilon < 0 {
+		panic("crown: negative uncertainty bound")
+	}
+	atomic.StoreInt64(&u.epsilon, int64(epsilon))
+}
+
+// NowInterval returns the interval which the true time is guaranteed to be
+// in: the time of the clock minus and plus ε.
+func (u *UncertainClock) NowInterval() (earliest, latest time.Time) {
+	now, epsilon := u.Now(), u.Epsilon()
+	return now.Add(-epsilon), now.Add(epsilon)
+}
+
+// DefinitelyAfter reports whether t has definitely passed, that is whether
+// the earliest possible true time is not before t.
+func (u *UncertainClock) DefinitelyAfter(t time.Time) bool {
+	earliest, _ := u.NowInterval()
+	return !earliest.Before(t)
+}
+
+// DefinitelyBefore reports whether t has definitely not arrived yet, that is
+// whether the latest possible true time is before t.
+func (u *UncertainClock) DefinitelyBefore(t time.Time) bool {
+	_, latest := u.NowInterval()
+	return latest.Before(t)
+}
+
+// WaitUntilAfter sleeps on the clock until t has definitely passed, see
+// DefinitelyAfter: until the clock reaches t+ε. This is the commit wait of a
+// transaction timestamped with t.
+func (u *UncertainClock) WaitUntilAfter(t time.Time) {
+	u.WaitUntilAfterContext(context.Background(), t)
+}
+
+// WaitUntilAfterContext is like WaitUntilAfter, but returns early when ctx is
+// done, the clock is closed or its waits are canceled, with an error like
+// Clock.SleepWithContext.
+func (u *UncertainClock) WaitUntilAfterContext(ctx context.Context, t time.Time) error {
+	handler, err := u.sleepUntil(ctx, t.Add(u.Epsilon()), u.origin(ctx, "sleep"))
+	handler.resume()
+	return err
+}
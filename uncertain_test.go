@@ -0,0 +1,47 @@
+package crown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUncertainClock(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-19T09:00:00Z")
+	u := NewUncertainClock(NewClock(refT, WithWakeAck()), 7*time.Millisecond)
+	earliest, latest := u.NowInterval()
+	if !earliest.Equal(refT.Add(-7*time.Millisecond)) || !latest.Equal(refT.Add(7*time.Millisecond)) {
+		t.Errorf("Interval should be ±7ms around %s, got [%s, %s]", refT, earliest, latest)
+	}
+	commit := refT
+	if u.DefinitelyAfter(commit) || u.DefinitelyBefore(commit.Add(7*time.Millisecond)) || !u.DefinitelyBefore(commit.Add(8*time.Millisecond)) {
+		t.Errorf("Should be uncertain within ε only")
+	}
+
+	// Commit wait.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		u.WaitUntilAfter(commit)
+	}()
+	u.BlockUntil(1)
+	u.Forward(6 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatalf("Commit wait should last ε")
+	default:
+	}
+	u.Forward(time.Millisecond)
+	<-done
+	if !u.DefinitelyAfter(commit) {
+		t.Errorf("Commit timestamp should have definitely passed")
+	}
+
+	u.SetEpsilon(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := u.WaitUntilAfterContext(ctx, u.Now()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait should be canceled, got %v", err)
+	}
+}
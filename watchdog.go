@@ -0,0 +1,79 @@
+package crown
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithWatchdog makes the clock call fn when waits have been pending on it for
+// the wall duration d without the clock moving nor any wait being registered,
+// such as when a test waits for a goroutine which waits for a Forward that
+// never comes. fn is called from a goroutine of the clock, once per stall. If
+// fn is nil, the pending waits are logged with the log package, along with the
+// stacks which registered them if the clock was created with
+// WithStackCapture, turning a silent hang into an actionable report. The
+// watchdog stops when the clock is closed. The duration d must be greater
+// than zero; if not, WithWatchdog panics.
+func WithWatchdog(d time.Duration, fn func(c *Clock)) Option {
+	if d <= 0 {
+		panic("crown: non-positive duration for WithWatchdog")
+	}
+	if fn == nil {
+		fn = func(c *Clock) {
+			log.Printf("crown: watchdog: the clock did not move for %s\n%s\n%v", d, c.DumpString(), c.CheckLeaks())
+		}
+	}
+	return func(c *Clock) {
+		w := &watchdog{d: d, fn: fn, epoch: time.Now(), stop: make(chan struct{})}
+		c.watchdog = w
+		c.addObserver(w.observe)
+	}
+}
+
+// watchdog reports the stalls of a clock.
+type watchdog struct {
+	d     time.Duration
+	fn    func(c *Clock)
+	epoch time.Time
+	last  int64 // wall time of the last activity, since epoch
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// observe records the activity of the clock.
+func (w *watchdog) observe(e Event) {
+	if e.Kind == EventAdvance || e.Kind == EventRegister {
+		atomic.StoreInt64(&w.last, int64(time.Since(w.epoch)))
+	}
+}
+
+// run runs the goroutine of the watchdog of c, until it is closed.
+func (w *watchdog) run(c *Clock) {
+	reported := int64(-1)
+	for {
+		last := atomic.LoadInt64(&w.last)
+		wait := time.Duration(last) + w.d - time.Since(w.epoch)
+		if wait <= 0 {
+			if last != reported && c.Waiters() > 0 {
+				w.fn(c)
+				reported = last
+			}
+			wait = w.d
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-w.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (w *watchdog) close() {
+	w.once.Do(func() {
+		close(w.stop)
+	})
+}
@@ -0,0 +1,95 @@
+package crown
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-09T09:00:00Z")
+	stalls := make(chan int, 10)
+	clock := NewClock(refT, WithWatchdog(20*time.Millisecond, func(c *Clock) {
+		stalls <- c.Waiters()
+	}))
+	defer clock.Close()
+
+	// An idle clock is not stalled.
+	select {
+	case <-stalls:
+		t.Fatalf("Watchdog fired without waits")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	go clock.Sleep(time.Hour)
+	select {
+	case n := <-stalls:
+		if n != 1 {
+			t.Errorf("Should report 1 waiter, got %d", n)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Watchdog did not fire")
+	}
+	// A stall is reported once.
+	select {
+	case <-stalls:
+		t.Fatalf("Watchdog fired twice for the same stall")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	timer := clock.NewTimer(time.Hour)
+	defer timer.Stop()
+	select {
+	case <-stalls:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Watchdog did not fire again after a registration")
+	}
+}
+
+func TestWatchdogLog(t *testing.T) {
+	var mu sync.Mutex
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return buf.Write(p)
+	}))
+
+	refT, _ := time.Parse(time.RFC3339, "2023-01-09T09:00:00Z")
+	clock := NewClock(refT, WithStackCapture(), WithWatchdog(10*time.Millisecond, nil))
+	timer := clock.NewTimer(time.Minute)
+	defer timer.Stop()
+	for try := 0; try < 1000; try++ {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		out := buf.String()
+		mu.Unlock()
+		if out != "" {
+			clock.Close()
+			if !strings.Contains(out, "the clock did not move for 10ms") || !strings.Contains(out, "crown.TestWatchdogLog") {
+				t.Errorf("Should log the pending waits and their stacks, got:\n%s", out)
+			}
+			return
+		}
+	}
+	t.Fatalf("Watchdog did not log")
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestWatchdogNonPositive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WithWatchdog(0) should panic")
+		}
+	}()
+	WithWatchdog(0, nil)
+}
@@ -0,0 +1,237 @@
+package crown
+
+import (
+	"container/heap"
+	"math/bits"
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 6
+)
+
+// WithTimerWheel makes the clock store its pending waits in a hierarchical
+// timer wheel with the resolution tick, instead of a heap. The wheel registers
+// waits in constant time, and releases them by buckets of ticks, which suits
+// simulations of millions of coarse-grained timeouts. Waits still fire at
+// their exact deadline, in the same order as with the heap. The wheel spans
+// 2^36 ticks from the time of the clock; later deadlines are kept in a heap.
+// The tick must be greater than zero; if not, WithTimerWheel panics.
+func WithTimerWheel(tick time.Duration) Option {
+	if tick <= 0 {
+		panic("crown: non-positive tick for WithTimerWheel")
+	}
+	return func(c *Clock) {
+		c.queue = &wheelQueue{origin: c.Now(), tick: tick}
+	}
+}
+
+// wheelQueue is a hierarchical timer wheel. Handlers are counted in ticks from
+// origin. Those due by the end of the tick cur are kept in the heap near,
+// along with those beyond the range of the wheel. The others are in the slots:
+// at level k, a slot holds the handlers whose tick only differs from cur from
+// the k-th group of wheelBits bits, the slot being the value of that group.
+// All the handlers of a level are thus due before those of the next level.
+type wheelQueue struct {
+	origin   time.Time
+	tick     time.Duration
+	cur      int64
+	near     waitHeap
+	slots    [wheelLevels][wheelSlots][]*sleepHandler
+	occupied [wheelLevels]uint64 // bit sets of the non-empty slots
+	byID     map[uint64]*sleepHandler
+}
+
+// tickOf returns the tick containing t.
+func (q *wheelQueue) tickOf(t time.Time) int64 {
+	d := t.Sub(q.origin)
+	n := int64(d / q.tick)
+	if d < 0 && d%q.tick != 0 {
+		n--
+	}
+	return n
+}
+
+func (q *wheelQueue) push(handler *sleepHandler) {
+	if q.byID == nil {
+		q.byID = make(map[uint64]*sleepHandler)
+	}
+	q.byID[handler.id] = handler
+	q.place(handler)
+}
+
+// place adds handler to the wheel, or to near if it is due by the end of the
+// tick cur or beyond the range of the wheel.
+func (q *wheelQueue) place(handler *sleepHandler) {
+	t := q.tickOf(handler.deadline)
+	if t > q.cur {
+		for k := 0; k < wheelLevels; k++ {
+			shift := wheelBits * (k + 1)
+			if t>>shift != q.cur>>shift {
+				continue
+			}
+			slot := int(t>>(wheelBits*k)) & wheelMask
+			s := &q.slots[k][slot]
+			handler.slot = k*wheelSlots + slot
+			handler.index = len(*s)
+			*s = append(*s, handler)
+			q.occupied[k] |= 1 << slot
+			return
+		}
+	}
+	handler.slot = -1
+	heap.Push(&q.near, handler)
+}
+
+func (q *wheelQueue) remove(key uint64) (*sleepHandler, bool) {
+	handler, ok := q.byID[key]
+	if !ok {
+		return nil, false
+	}
+	delete(q.byID, key)
+	if handler.slot < 0 {
+		heap.Remove(&q.near, handler.index)
+		return handler, true
+	}
+	k, slot := handler.slot/wheelSlots, handler.slot%wheelSlots
+	s := &q.slots[k][slot]
+	last := len(*s) - 1
+	moved := (*s)[last]
+	(*s)[handler.index] = moved
+	moved.index = handler.index
+	(*s)[last] = nil
+	*s = (*s)[:last]
+	if last == 0 {
+		q.occupied[k] &^= 1 << slot
+	}
+	return handler, true
+}
+
+// take empties the slot of level k, and returns its handlers.
+func (q *wheelQueue) take(k, slot int) []*sleepHandler {
+	handlers := q.slots[k][slot]
+	q.slots[k][slot] = nil
+	q.occupied[k] &^= 1 << slot
+	return handlers
+}
+
+// flush moves the handlers of the slots of level k in the range [from, to)
+// to near.
+func (q *wheelQueue) flush(k, from, to int) {
+	for {
+		occupied := q.occupied[k] >> from << from
+		if occupied == 0 {
+			return
+		}
+		slot := bits.TrailingZeros64(occupied)
+		if slot >= to {
+			return
+		}
+		for _, handler := range q.take(k, slot) {
+			handler.slot = -1
+			heap.Push(&q.near, handler)
+		}
+	}
+}
+
+// advance moves cur forward to the tick t, moving the handlers due by the end
+// of t to near.
+func (q *wheelQueue) advance(t int64) {
+	for q.cur < t {
+		k := 0
+		for k < wheelLevels && q.cur>>(wheelBits*(k+1)) != t>>(wheelBits*(k+1)) {
+			k++
+		}
+		if k == wheelLevels {
+			// t is beyond the range of the wheel, which is due as a whole.
+			for k := range q.slots {
+				q.flush(k, 0, wheelSlots)
+			}
+			q.cur = t
+			return
+		}
+		// The lower levels, and the slots of level k up to the one of t, are
+		// due as a whole.
+		for j := 0; j < k; j++ {
+			q.flush(j, 0, wheelSlots)
+		}
+		shift := wheelBits * k
+		to := int(t>>shift) & wheelMask
+		q.flush(k, 0, to)
+		if k == 0 {
+			q.flush(0, to, to+1)
+			q.cur = t
+			return
+		}
+		// The slot of t is due up to t: spread it over the lower levels.
+		q.cur = t >> shift << shift
+		for _, handler := range q.take(k, to) {
+			q.place(handler)
+		}
+	}
+}
+
+// first returns the first handler of the slots, or nil if they are empty.
+func (q *wheelQueue) first() *sleepHandler {
+	for k := range q.occupied {
+		if q.occupied[k] == 0 {
+			continue
+		}
+		var first *sleepHandler
+		for _, handler := range q.slots[k][bits.TrailingZeros64(q.occupied[k])] {
+			if first == nil || handler.before(first) {
+				first = handler
+			}
+		}
+		return first
+	}
+	return nil
+}
+
+func (q *wheelQueue) popDue(now time.Time, last uint64) *sleepHandler {
+	q.advance(q.tickOf(now))
+	if len(q.near) == 0 {
+		return nil
+	}
+	first := q.near[0]
+	if now.Before(first.deadline) || first.id > last {
+		return nil
+	}
+	heap.Pop(&q.near)
+	delete(q.byID, first.id)
+	return first
+}
+
+func (q *wheelQueue) next() (time.Time, bool) {
+	next, ok := time.Time{}, false
+	if len(q.near) > 0 {
+		next, ok = q.near[0].deadline, true
+	}
+	if first := q.first(); first != nil && (!ok || first.deadline.Before(next)) {
+		next, ok = first.deadline, true
+	}
+	return next, ok
+}
+
+func (q *wheelQueue) deadlineAfter(t time.Time) (time.Time, bool) {
+	// Once advanced to t, the slots only hold deadlines after t.
+	q.advance(q.tickOf(t))
+	next, ok := q.near.deadlineAfter(t)
+	if first := q.first(); first != nil && (!ok || first.deadline.Before(next)) {
+		next, ok = first.deadline, true
+	}
+	return next, ok
+}
+
+func (q *wheelQueue) handlers() []*sleepHandler {
+	handlers := append([]*sleepHandler(nil), q.near...)
+	for k := range q.slots {
+		for _, s := range q.slots[k] {
+			handlers = append(handlers, s...)
+		}
+	}
+	return handlers
+}
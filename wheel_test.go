@@ -0,0 +1,97 @@
+package crown
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// TestTimerWheel checks that a clock with a timer wheel fires the same waits
+// at the same times, in the same order, as a clock with the default heap.
+func TestTimerWheel(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-06T09:00:00Z")
+	type fire struct {
+		wait int
+		at   time.Time
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		clocks := []*Clock{NewClock(refT), NewClock(refT, WithTimerWheel(time.Millisecond))}
+		fires := make([][]fire, len(clocks))
+		keys := make([][]uint64, len(clocks))
+		for step := 0; step < 2000; step++ {
+			op := rng.Intn(10)
+			// Deadlines spread over several levels of the wheel, and beyond.
+			d := time.Duration(rng.Int63n(int64(time.Millisecond) << (6 * rng.Intn(8))))
+			div := time.Duration(1 + rng.Intn(64))
+			n := len(keys[0])
+			victim := 0
+			if n > 0 {
+				victim = rng.Intn(n)
+			}
+			for i, c := range clocks {
+				i, c := i, c
+				switch {
+				case op < 5:
+					wait := n
+					key, _ := c.schedule(&sleepHandler{
+						deadline: c.Now().Add(d),
+						fire: func(err error) {
+							if err == nil {
+								fires[i] = append(fires[i], fire{wait, c.Now()})
+							}
+						},
+					})
+					keys[i] = append(keys[i], key)
+				case op < 6:
+					if n > 0 {
+						c.cancel(keys[i][victim], ErrCanceled)
+					}
+				case op < 8:
+					c.Forward(d / div)
+				case op < 9:
+					c.AdvanceToNext()
+				default:
+					c.Set(c.Now().Add(-d / 1024))
+				}
+			}
+			if got, want := clocks[1].Waiters(), clocks[0].Waiters(); got != want {
+				t.Fatalf("seed %d, step %d: %d waiters, want %d", seed, step, got, want)
+			}
+			gotNext, gotOK := clocks[1].NextDeadline()
+			wantNext, wantOK := clocks[0].NextDeadline()
+			if gotOK != wantOK || !gotNext.Equal(wantNext) {
+				t.Fatalf("seed %d, step %d: next deadline %v, want %v", seed, step, gotNext, wantNext)
+			}
+		}
+		clocks[0].RunUntilIdle()
+		clocks[1].RunUntilIdle()
+		if len(fires[0]) != len(fires[1]) {
+			t.Fatalf("seed %d: %d fires, want %d", seed, len(fires[1]), len(fires[0]))
+		}
+		for i := range fires[0] {
+			if fires[1][i] != fires[0][i] {
+				t.Fatalf("seed %d: fire %d is %+v, want %+v", seed, i, fires[1][i], fires[0][i])
+			}
+		}
+	}
+}
+
+func TestTimerWheelStepwise(t *testing.T) {
+	refT, _ := time.Parse(time.RFC3339, "2023-01-06T09:00:00Z")
+	clock := NewClock(refT, WithTimerWheel(time.Second), WithStepwiseForward())
+	var got []time.Time
+	for _, d := range []time.Duration{time.Hour, 1500 * time.Millisecond, 24 * time.Hour, time.Minute} {
+		clock.schedule(&sleepHandler{
+			deadline: refT.Add(d),
+			fire:     func(error) { got = append(got, clock.Now()) },
+		})
+	}
+	clock.Forward(48 * time.Hour)
+	want := []time.Duration{1500 * time.Millisecond, time.Minute, time.Hour, 24 * time.Hour}
+	for i, d := range want {
+		if !got[i].Equal(refT.Add(d)) {
+			t.Errorf("Wait %d should fire at %v, got %v", i, refT.Add(d), got[i])
+		}
+	}
+}